@@ -12,7 +12,6 @@ var configFile = flag.String("config", "core-config.yaml", "Core config file")
 func main() {
 	flag.Parse()
 	core.Init(*configFile)
-	defer core.Release()
 
 	core.UserCorsMiddleware()
 
@@ -22,5 +21,5 @@ func main() {
 	core.RegisterAPI("/removeAccount", http.MethodPost, handlers.RemoveAccount)
 	core.RegisterAPI("/removeAllAccount", http.MethodPost, handlers.RemoveAllAccount)
 
-	core.Start()
+	core.Run()
 }
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	ACCESS_LOG_FORMAT_COMMON   = "common"
+	ACCESS_LOG_FORMAT_COMBINED = "combined"
+	ACCESS_LOG_FORMAT_JSON     = "json"
+	ACCESS_LOG_TIME_FORMAT     = "02/Jan/2006:15:04:05 -0700"
+)
+
+var accessLogger = log.New(os.Stdout, BLANK, 0)
+
+// accessLogFormat: format of access log written for every request, blank when access log is disabled
+var accessLogFormat = BLANK
+
+/*
+* accessLogEntry: a line of access log in json format
+ */
+type accessLogEntry struct {
+	Time      string  `json:"time"`
+	RequestID string  `json:"request_id"`
+	RemoteIP  string  `json:"remote_ip"`
+	Method    string  `json:"method"`
+	Path      string  `json:"path"`
+	Protocol  string  `json:"protocol"`
+	Status    int     `json:"status"`
+	Bytes     int64   `json:"bytes"`
+	Duration  float64 `json:"duration_ms"`
+	Referer   string  `json:"referer"`
+	UserAgent string  `json:"user_agent"`
+}
+
+/*
+* SetAccessLogOutput: set writer of access log, default: stdout
+* @params: writer io.Writer
+* @return: void
+ */
+func SetAccessLogOutput(writer io.Writer) {
+	accessLogger.SetOutput(writer)
+}
+
+/*
+* AccessLog: middleware write a line of access log when request is done
+* Format common, combined are the formats of apache, json has request id and duration
+* @params: format string (ACCESS_LOG_FORMAT_COMMON, ACCESS_LOG_FORMAT_COMBINED, ACCESS_LOG_FORMAT_JSON)
+* @return: Middleware
+ */
+func AccessLog(format string) Middleware {
+	format = checkAccessLogFormat(format)
+	return func(ctx *Context) HttpError {
+		ctx.logAccess(format)
+		ctx.Next()
+		return nil
+	}
+}
+
+/*
+* checkAccessLogFormat: return format if it is supported, otherwise ACCESS_LOG_FORMAT_COMBINED
+ */
+func checkAccessLogFormat(format string) string {
+	if format != ACCESS_LOG_FORMAT_COMMON && format != ACCESS_LOG_FORMAT_COMBINED && format != ACCESS_LOG_FORMAT_JSON {
+		LoggerInstance.Warning("Access log format %s is not supported, use %s", format, ACCESS_LOG_FORMAT_COMBINED)
+		return ACCESS_LOG_FORMAT_COMBINED
+	}
+	return format
+}
+
+/*
+* logAccess: write a line of access log when request is finished
+ */
+func (ctx *Context) logAccess(format string) {
+	start := time.Now()
+	ctx.OnFinish(func(ctx *Context) {
+		accessLogger.Println(formatAccessLog(ctx, format, start, time.Since(start)))
+	})
+}
+
+/*
+* initAccessLog: enable access log by config
+* Access log is written by serveHTTP instead of a middleware, so unrouted and 405 responses are also recorded
+ */
+func initAccessLog() {
+	if Config.AccessLog.File != BLANK {
+		file, err := os.OpenFile(Config.AccessLog.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			LoggerInstance.Error("Open access log file %s fail: %s", Config.AccessLog.File, err.Error())
+		} else {
+			SetAccessLogOutput(file)
+		}
+	}
+	accessLogFormat = checkAccessLogFormat(Config.AccessLog.GetFormat())
+}
+
+/*
+* formatAccessLog: format a line of access log
+ */
+func formatAccessLog(ctx *Context, format string, start time.Time, duration time.Duration) string {
+	request := ctx.request
+	remoteIP := ctx.GetRemoteIP()
+
+	if format == ACCESS_LOG_FORMAT_JSON {
+		entry := accessLogEntry{
+			Time:      start.Format(time.RFC3339Nano),
+			RequestID: ctx.requestID,
+			RemoteIP:  remoteIP,
+			Method:    request.Method,
+			Path:      request.URL.RequestURI(),
+			Protocol:  request.Proto,
+			Status:    ctx.GetResponseStatus(),
+			Bytes:     ctx.GetResponseSize(),
+			Duration:  float64(duration.Microseconds()) / 1000,
+			Referer:   request.Referer(),
+			UserAgent: request.UserAgent(),
+		}
+		data, _ := json.Marshal(entry)
+		return string(data)
+	}
+
+	size := "-"
+	if ctx.GetResponseSize() > 0 {
+		size = fmt.Sprintf("%d", ctx.GetResponseSize())
+	}
+	line := fmt.Sprintf(`%s - - [%s] "%s %s %s" %d %s`, remoteIP, start.Format(ACCESS_LOG_TIME_FORMAT),
+		request.Method, request.URL.RequestURI(), request.Proto, ctx.GetResponseStatus(), size)
+	if format == ACCESS_LOG_FORMAT_COMBINED {
+		line += fmt.Sprintf(` %q %q`, request.Referer(), request.UserAgent())
+	}
+	return line
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newAccessLogTestContext() *Context {
+	request := httptest.NewRequest(http.MethodGet, "/items?page=1", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.Header.Set("User-Agent", "test-agent")
+	ctx := &Context{request: request, requestID: "1"}
+	ctx.response = responseWriter{ResponseWriter: httptest.NewRecorder()}
+	ctx.rw = &ctx.response
+	return ctx
+}
+
+func TestFormatAccessLog_Combined(t *testing.T) {
+	ctx := newAccessLogTestContext()
+	ctx.endResponse(http.StatusNotFound, "not found")
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	line := formatAccessLog(ctx, ACCESS_LOG_FORMAT_COMBINED, start, time.Millisecond)
+
+	expected := `10.0.0.1 - - [02/Jan/2024:03:04:05 +0000] "GET /items?page=1 HTTP/1.1" 404 9 "" "test-agent"`
+	if line != expected {
+		t.Errorf("Expected %s, got %s", expected, line)
+	}
+}
+
+func TestAccessLog_JsonWrittenOnFinish(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	SetAccessLogOutput(buffer)
+	defer SetAccessLogOutput(os.Stdout)
+
+	ctx := newAccessLogTestContext()
+	AccessLog(ACCESS_LOG_FORMAT_JSON)(ctx)
+	if buffer.Len() != 0 {
+		t.Fatalf("Expected access log is written when request is done, got %s", buffer.String())
+	}
+
+	ctx.endResponse(http.StatusCreated, "created")
+	ctx.runFinishHooks()
+
+	entry := accessLogEntry{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(buffer.String())), &entry); err != nil {
+		t.Fatalf("Unmarshal access log fail: %s", err.Error())
+	}
+	if entry.Status != http.StatusCreated || entry.Bytes != 7 || entry.RequestID != "1" || entry.RemoteIP != "10.0.0.1" {
+		t.Errorf("Unexpected access log: %+v", entry)
+	}
+}
+
+func TestAccessLog_UnroutedAndMethodNotAllowed(t *testing.T) {
+	useTestRouter(t)
+	RegisterAPI("/items", http.MethodGet, corsTestHandler)
+	buffer := &bytes.Buffer{}
+	SetAccessLogOutput(buffer)
+	accessLogFormat = ACCESS_LOG_FORMAT_JSON
+	defer func() {
+		SetAccessLogOutput(os.Stdout)
+		accessLogFormat = BLANK
+	}()
+
+	serveTestRequest(http.MethodGet, "/items", nil)
+	serveTestRequest(http.MethodGet, "/missing", nil)
+	serveTestRequest(http.MethodDelete, "/items", nil)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines of access log, got %d: %s", len(lines), buffer.String())
+	}
+	expected := []int{http.StatusOK, http.StatusNotFound, http.StatusMethodNotAllowed}
+	for i, line := range lines {
+		entry := accessLogEntry{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Unmarshal access log fail: %s", err.Error())
+		}
+		if entry.Status != expected[i] || entry.RequestID != "test" {
+			t.Errorf("Expected status %d, got %+v", expected[i], entry)
+		}
+	}
+}
@@ -2,12 +2,10 @@ package core
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"reflect"
-	"strings"
 
 	"github.com/go-playground/validator"
 )
@@ -15,62 +13,146 @@ import (
 type Route struct {
 	URL     string
 	Method  string
-	handler func(writer http.ResponseWriter, request *http.Request)
+	handler routeHandler
+	info    *routeInfo
 }
 
+type routeHandler func(writer http.ResponseWriter, request *http.Request, params pathParams)
+
 type Middleware func(ctx *Context) HttpError
 
 type Handler[T any] func(ctx *Context, request T) (HttpResponse, HttpError)
 
+/*
+* TypedHandler: handler returns response data with type Res instead of HttpResponse
+* Response data is written with status code 200 and wrapped by response envelope
+ */
+type TypedHandler[Req any, Res any] func(ctx *Context, request Req) (Res, HttpError)
+
 /*
 * Register api: register api to routeMap
+* Url can contain param segments: /items/{id} and a wildcard as the last segment: /files/*rest
+* Value of them can be got by ctx.GetPathParam
+* Fields of T with tags: path:"id", query:"page", header:"X-Tenant", form:"name"
+* and file:"avatar" (*multipart.FileHeader) are bound from request before validating
 * @param url: url of api
 * @param handler: handler of api
 * @param middleware: middleware of api
 * @return void
  */
 func RegisterAPI[T any](url string, method string, handler Handler[T], middlewares ...Middleware) {
+	registerAPI(nil, url, method, handler, middlewares)
+}
+
+/*
+* RegisterTypedAPI: register api with typed response to routeMap
+* @param url: url of api
+* @param handler: handler of api
+* @param middleware: middleware of api
+* @return void
+ */
+func RegisterTypedAPI[Req any, Res any](url string, method string, handler TypedHandler[Req, Res], middlewares ...Middleware) {
+	registerTypedAPI(nil, url, method, handler, middlewares)
+}
+
+/*
+* registerTypedAPI: convert typed handler to handler and register it
+ */
+func registerTypedAPI[Req any, Res any](group *RouteGroup, url string, method string, handler TypedHandler[Req, Res], middlewares []Middleware) {
+	h := func(ctx *Context, request Req) (HttpResponse, HttpError) {
+		res, err := handler(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		return NewDefaultHttpResponse(res), nil
+	}
+
+	var res Res
+	info := registerAPI(group, url, method, h, middlewares)
+	info.responseType = reflect.TypeOf(res)
+}
+
+/*
+* registerAPI: register api of a group to routeMap, group is nil when api is not in any group
+* @return *routeInfo: info of registered route
+ */
+func registerAPI[T any](group *RouteGroup, url string, method string, handler Handler[T], middlewares []Middleware) *routeInfo {
+	url = group.getUrl(url)
 	LoggerInstance.Info("Register api: %s %s", method, url)
+	var t T
+	binder := newRequestBinder(reflect.TypeOf(t))
+	cors := group.getCors()
 	// Create a new handler
-	h := func(writer http.ResponseWriter, request *http.Request) {
+	h := func(writer http.ResponseWriter, request *http.Request, params pathParams) {
 		// Create a new context
 		ctx := getContext()
 		defer putContext(ctx)
-		buildContext(ctx, writer, request)
+		defer ctx.recoverPanic()
+		buildContext(ctx, writer, request, params)
+		ctx.instrumentRequest(url)
+		ctx.corsPolicy = cors
 
 		// Append to common middleware
 		middlewareList := []Middleware{}
 		middlewareList = append(middlewareList, commonMiddlewares...)
+		if cors != nil {
+			middlewareList = append(middlewareList, cors.middleware())
+		}
+		middlewareList = append(middlewareList, group.getMiddlewares()...)
 		middlewareList = append(middlewareList, middlewares...)
 
 		// Call middleware of function
-		for _, middleware := range middlewareList {
-			ctx.isRequestEnd = true
-			if err := middleware(ctx); ctx.isRequestEnd {
-				if err != nil {
-					ctx.writeError(err)
-				}
-				return
-			}
+		if ctx.runMiddlewares(middlewareList) {
+			return
+		}
+
+		// Check media type of response that user accepts
+		if ctx.responseMediaType == BLANK {
+			ctx.writeError(HTTP_ERROR_NOT_ACCEPTABLE)
+			return
+		}
+
+		// Read request body after middlewares, so body limit of api is applied
+		if err := ctx.readBody(); err != nil {
+			ctx.writeError(err)
+			return
 		}
 
-		// Unmarshal json request body to model T
+		// Decode request body to model T by codec of Content-Type
 		req := initRequest[T]()
-		requestContentType := strings.ToLower(ctx.GetRequestHeader(CONTENT_TYPE_KEY))
 		if len(ctx.requestBody) != 0 {
-			if strings.Contains(requestContentType, JSON_CONTENT_TYPE) {
-				if err := json.Unmarshal(ctx.requestBody, &req); err != nil {
+			mediaType := parseMediaType(ctx.GetRequestHeader(CONTENT_TYPE_KEY))
+			if mediaType == FORMDATA_CONTENT_TYPE {
+				buffer := bytes.NewBuffer(ctx.requestBody)
+				ctx.request.Body = io.NopCloser(buffer)
+				ctx.request.ParseForm()
+			} else if codec := getCodec(mediaType); codec != nil {
+				if err := codec.Unmarshal(ctx.requestBody, req); err != nil {
 					LoggerInstance.Info("Unmarshal request body fail. RequestId: %s, Error: %s", ctx.requestID, err.Error())
 					ctx.writeError(NewDefaultHttpError(400, "Bad request (Marshal requeset body)"))
 					return
 				}
-			} else if strings.Contains(requestContentType, FORMDATA_CONTENT_TYPE) {
-				buffer := bytes.NewBuffer(ctx.requestBody)
-				ctx.request.Body = io.NopCloser(buffer)
-				ctx.request.ParseForm()
+			} else if mediaType != BLANK {
+				ctx.LogInfo("Content type is not supported: %s", mediaType)
+				ctx.writeError(HTTP_ERROR_UNSUPPORTED_MEDIA_TYPE)
+				return
+			}
+		}
+
+		// Parse multipart body if model T has form or file fields
+		if binder.hasFormFields && isMultipart(ctx.request) {
+			if err := ctx.parseMultipartForm(); err != nil {
+				ctx.writeError(err)
+				return
 			}
 		}
 
+		// Bind path, query, header, form values and files to model T
+		if err := binder.bind(ctx, req); err != nil {
+			ctx.writeError(err)
+			return
+		}
+
 		// Validate go struct with tag
 		errValidate := validate.Struct(req)
 		if errValidate != nil {
@@ -97,24 +179,30 @@ func RegisterAPI[T any](url string, method string, handler Handler[T], middlewar
 		}
 	}
 
-	routeSlice, ok := routeMap[url]
-	if ok {
-		routeSlice = append(routeSlice, Route{
-			Method:  method,
-			URL:     url,
-			handler: h,
-		})
-		routeMap[url] = routeSlice
-	} else {
-		routeMap[url] = []Route{
-			{
-				Method:  method,
-				URL:     url,
-				handler: h,
-			},
-		}
+	info := &routeInfo{
+		requestType: reflect.TypeOf(t),
+		cors:        cors,
 	}
+	registerRoute(url, method, h, info)
+	return info
+}
 
+/*
+* RegisterResponse: describe type of response data of a registered api
+* It is used to build response schema in api document
+* @param url: full url of api (include prefix of group)
+* @param method: method of api
+* @return void
+ */
+func RegisterResponse[Res any](url string, method string) {
+	var res Res
+	for _, route := range routeMap[url] {
+		if route.Method == method {
+			route.info.responseType = reflect.TypeOf(res)
+			return
+		}
+	}
+	LoggerInstance.Error("Register response fail: api %s %s is not registered", method, url)
 }
 
 func initRequest[T any]() T {
@@ -123,30 +211,41 @@ func initRequest[T any]() T {
 	return ref.Interface().(T)
 }
 
-func buildContext(ctx *Context, writer http.ResponseWriter, request *http.Request) HttpError {
+/*
+* instrumentRequest: save route of request, start span, record metrics and access log of request
+* @params: route string (url pattern of api)
+ */
+func (ctx *Context) instrumentRequest(route string) {
+	ctx.route = route
+	if accessLogFormat != BLANK {
+		ctx.logAccess(accessLogFormat)
+	}
+	ctx.startServerSpan(route)
+	ctx.observeRequest(route)
+}
+
+func buildContext(ctx *Context, writer http.ResponseWriter, request *http.Request, params pathParams) {
 	// Assign response writer and request
-	ctx.rw = writer
+	ctx.response = responseWriter{ResponseWriter: writer}
+	ctx.rw = &ctx.response
 	ctx.request = request
-
-	ctx.requestID = ID.GenerateID()
+	ctx.pathParams = append(ctx.pathParams[:0], params...)
+	ctx.allowMethods = BLANK
+	ctx.corsPolicy = nil
+	ctx.claims = nil
+	ctx.compressWriter = nil
+	ctx.etagMode = BLANK
+	ctx.envelope = nil
+	ctx.responseMediaType = negotiateMediaType(request.Header.Get(ACCEPT_KEY))
+	ctx.uploadLimit = 0
+	ctx.isUploadLimited = false
+	ctx.bodyLimit = 0
+	ctx.isBodyLimited = false
+	ctx.isStreamBody = false
+
+	decompressBody(request)
+	ctx.initRequestID(request)
 	// Get url
 	ctx.URL = request.URL.Path
 	ctx.Method = request.Method
-
-	// Get request body
-	buffer := bytes.NewBuffer(ctx.requestBody)
-	buffer.Reset()
-
-	if _, err := io.Copy(buffer, request.Body); err != nil {
-		LoggerInstance.Error("Read request body fail. RequestId: %s, Error: %s", ctx.requestID, err.Error())
-		return HTTP_ERROR_READ_BODY_REQUEST_FAIL
-	}
-
-	if err := request.Body.Close(); err != nil {
-		LoggerInstance.Error("Close request body fail. RequestId: %s, Error: %s", ctx.requestID, err.Error())
-		return HTTP_ERROR_CLOSE_BODY_REQUEST_FAIL
-	}
-
-	ctx.requestBody = buffer.Bytes()
-	return nil
 }
@@ -0,0 +1,266 @@
+package core
+
+import (
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+const (
+	BIND_TAG_PATH        = "path"
+	BIND_TAG_QUERY       = "query"
+	BIND_TAG_HEADER      = "header"
+	BIND_TAG_FORM        = "form"
+	BIND_TAG_FILE        = "file"
+	BIND_TAG_TIME_FORMAT = "time_format"
+)
+
+var bindTags = []string{BIND_TAG_PATH, BIND_TAG_QUERY, BIND_TAG_HEADER, BIND_TAG_FORM, BIND_TAG_FILE}
+
+var timeType = reflect.TypeOf(time.Time{})
+var durationType = reflect.TypeOf(time.Duration(0))
+var fileHeaderType = reflect.TypeOf(&multipart.FileHeader{})
+var fileHeadersType = reflect.TypeOf([]*multipart.FileHeader{})
+
+/*
+* bindField: a field of request struct that is bound from path, query, header or form
+ */
+type bindField struct {
+	index      []int
+	source     string
+	name       string
+	timeFormat string
+}
+
+/*
+* requestBinder: hold all bind fields of a request type
+* It is built once when api is registered
+ */
+type requestBinder struct {
+	fields        []bindField
+	hasFormFields bool
+}
+
+/*
+* newRequestBinder: build binder from type of request
+* @params: t reflect.Type (struct or pointer of struct)
+* @return: *requestBinder
+ */
+func newRequestBinder(t reflect.Type) *requestBinder {
+	binder := &requestBinder{}
+	if t == nil {
+		return binder
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return binder
+	}
+
+	binder.collectFields(t, nil)
+	return binder
+}
+
+func (binder *requestBinder) collectFields(t reflect.Type, parentIndex []int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		index := append(append([]int{}, parentIndex...), i)
+
+		// Bind fields of embedded struct
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			binder.collectFields(field.Type, index)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		for _, source := range bindTags {
+			name := field.Tag.Get(source)
+			if name == BLANK || name == "-" {
+				continue
+			}
+
+			binder.fields = append(binder.fields, bindField{
+				index:      index,
+				source:     source,
+				name:       name,
+				timeFormat: field.Tag.Get(BIND_TAG_TIME_FORMAT),
+			})
+			if source == BIND_TAG_FORM || source == BIND_TAG_FILE {
+				binder.hasFormFields = true
+			}
+		}
+	}
+}
+
+/*
+* bind: set value of path, query, header and form into request
+* @params: ctx *Context, request any (pointer of struct)
+* @return: HttpError
+ */
+func (binder *requestBinder) bind(ctx *Context, request any) HttpError {
+	if len(binder.fields) == 0 {
+		return nil
+	}
+
+	v := reflect.ValueOf(request)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil
+	}
+	v = v.Elem()
+
+	var query map[string][]string
+	for _, field := range binder.fields {
+		var values []string
+		switch field.source {
+		case BIND_TAG_PATH:
+			if value, ok := ctx.pathParams.get(field.name); ok {
+				values = []string{value}
+			}
+		case BIND_TAG_QUERY:
+			if query == nil {
+				query = ctx.request.URL.Query()
+			}
+			values = query[field.name]
+		case BIND_TAG_HEADER:
+			values = ctx.request.Header.Values(field.name)
+		case BIND_TAG_FORM:
+			if ctx.request.PostForm != nil {
+				values = ctx.request.PostForm[field.name]
+			}
+		case BIND_TAG_FILE:
+			if err := bindFile(v.FieldByIndex(field.index), ctx.request.MultipartForm, field.name); err != nil {
+				ctx.LogInfo("Bind request fail: %s %s, err = %s", field.source, field.name, err.Error())
+				return NewHttpError(http.StatusBadRequest, ERROR_BIND_REQUEST_FAIL, fmt.Sprintf("Request invalid: {%s: %s}", field.source, field.name), nil)
+			}
+			continue
+		}
+
+		if len(values) == 0 {
+			continue
+		}
+
+		if err := setFieldValue(v.FieldByIndex(field.index), values, field.timeFormat); err != nil {
+			ctx.LogInfo("Bind request fail: %s %s, err = %s", field.source, field.name, err.Error())
+			return NewHttpError(http.StatusBadRequest, ERROR_BIND_REQUEST_FAIL, fmt.Sprintf("Request invalid: {%s: %s, Value: %v}", field.source, field.name, values), nil)
+		}
+	}
+
+	return nil
+}
+
+/*
+* bindFile: set uploaded files to field, type of field must be *multipart.FileHeader or []*multipart.FileHeader
+ */
+func bindFile(field reflect.Value, form *multipart.Form, name string) error {
+	if form == nil || len(form.File[name]) == 0 {
+		return nil
+	}
+
+	switch field.Type() {
+	case fileHeaderType:
+		field.Set(reflect.ValueOf(form.File[name][0]))
+	case fileHeadersType:
+		field.Set(reflect.ValueOf(form.File[name]))
+	default:
+		return fmt.Errorf("type %s is not supported for file", field.Type().String())
+	}
+	return nil
+}
+
+/*
+* setFieldValue: convert values from string to type of field and set it
+* Slice field receives all values, other fields receive the first value
+ */
+func setFieldValue(field reflect.Value, values []string, timeFormat string) error {
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), value, timeFormat); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	return setValue(field, values[0], timeFormat)
+}
+
+/*
+* setValue: convert a string value to type of field and set it
+ */
+func setValue(field reflect.Value, value string, timeFormat string) error {
+	if field.Kind() == reflect.Pointer {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setValue(ptr.Elem(), value, timeFormat); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	switch field.Type() {
+	case timeType:
+		if timeFormat == BLANK {
+			timeFormat = time.RFC3339
+		}
+		t, err := time.Parse(timeFormat, value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("type %s is not supported", field.Type().String())
+		}
+		field.SetBytes([]byte(value))
+	default:
+		return fmt.Errorf("type %s is not supported", field.Type().String())
+	}
+
+	return nil
+}
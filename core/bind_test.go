@@ -0,0 +1,77 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type bindTestPaging struct {
+	Page int `query:"page"`
+	Size int `query:"size"`
+}
+
+type bindTestRequest struct {
+	bindTestPaging
+	Id       uint64    `path:"id"`
+	Tenant   string    `header:"X-Tenant"`
+	Active   bool      `query:"active"`
+	Tags     []string  `query:"tag"`
+	Ids      []int     `query:"ids"`
+	From     time.Time `query:"from" time_format:"2006-01-02"`
+	Limit    *int      `query:"limit"`
+	Name     string    `json:"name"`
+	Unbound  string
+	internal string `query:"internal"`
+}
+
+func TestBindRequest_Success(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/items/12?page=2&size=20&active=true&tag=a&tag=b&ids=1&ids=2&from=2024-01-31&limit=5&internal=x", nil)
+	request.Header.Set("X-Tenant", "tenant_1")
+	ctx := &Context{
+		request:    request,
+		pathParams: pathParams{{key: "id", value: "12"}},
+	}
+
+	req := &bindTestRequest{Name: "from_json"}
+	binder := newRequestBinder(reflect.TypeOf(req))
+	if err := binder.bind(ctx, req); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	limit := 5
+	expected := &bindTestRequest{
+		bindTestPaging: bindTestPaging{Page: 2, Size: 20},
+		Id:             12,
+		Tenant:         "tenant_1",
+		Active:         true,
+		Tags:           []string{"a", "b"},
+		Ids:            []int{1, 2},
+		From:           time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+		Limit:          &limit,
+		Name:           "from_json",
+	}
+	if !reflect.DeepEqual(req, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, req)
+	}
+}
+
+func TestBindRequest_InvalidValue(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/items/abc", nil)
+	ctx := &Context{
+		request:    request,
+		pathParams: pathParams{{key: "id", value: "abc"}},
+	}
+
+	req := &bindTestRequest{}
+	binder := newRequestBinder(reflect.TypeOf(req))
+	err := binder.bind(ctx, req)
+	if err == nil {
+		t.Fatalf("Expected error, got nil")
+	}
+	if err.GetStatusCode() != http.StatusBadRequest || err.GetCode() != ERROR_BIND_REQUEST_FAIL {
+		t.Errorf("Expected bad request, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+)
+
+const (
+	DEFAULT_REQUEST_BODY_CAPACITY = 16 << 10 // 16 KB
+	MAX_POOLED_BODY_CAPACITY      = 1 << 20  // 1 MB
+)
+
+/*
+* MaxBodyBytes: middleware set max size of request body of api
+* It overrides server.max_body_bytes in config, request is rejected with 413 when body is larger
+* @params: maxBytes int64
+* @return: Middleware
+ */
+func MaxBodyBytes(maxBytes int64) Middleware {
+	return func(ctx *Context) HttpError {
+		ctx.bodyLimit = maxBytes
+		ctx.Next()
+		return nil
+	}
+}
+
+/*
+* StreamBody: middleware make api skip reading request body to memory
+* Handler reads body as a stream by ctx.Body(), request model is bound from path, query, header only
+* @return: Middleware
+ */
+func StreamBody() Middleware {
+	return func(ctx *Context) HttpError {
+		ctx.isStreamBody = true
+		ctx.Next()
+		return nil
+	}
+}
+
+/*
+* Body: get reader of request body
+* Body of streaming api is read directly from connection, it is limited by max body bytes
+* @return: io.Reader
+ */
+func (ctx *Context) Body() io.Reader {
+	if ctx.isStreamBody {
+		ctx.limitBody()
+		return ctx.request.Body
+	}
+	return bytes.NewReader(ctx.requestBody)
+}
+
+/*
+* limitBody: limit size of request body by limit of api or config
+ */
+func (ctx *Context) limitBody() {
+	if ctx.isBodyLimited {
+		return
+	}
+	ctx.isBodyLimited = true
+
+	limit := Config.Server.MaxBodyBytes
+	if ctx.bodyLimit > 0 {
+		limit = ctx.bodyLimit
+	}
+	if limit > 0 {
+		ctx.request.Body = http.MaxBytesReader(ctx.rw, ctx.request.Body, limit)
+	}
+}
+
+/*
+* readBody: read request body to ctx.requestBody
+* Body of streaming api and multipart body are not read here
+* @return: HttpError
+ */
+func (ctx *Context) readBody() HttpError {
+	ctx.requestBody = ctx.requestBody[:0]
+	if ctx.isStreamBody || isMultipart(ctx.request) {
+		return nil
+	}
+
+	ctx.limitBody()
+	buffer := bytes.NewBuffer(ctx.requestBody)
+	if _, err := io.Copy(buffer, ctx.request.Body); err != nil {
+		ctx.LogInfo("Read request body fail: %s", err.Error())
+		if isMaxBytesError(err) {
+			return HTTP_ERROR_REQUEST_ENTITY_TOO_LARGE
+		}
+		if isDecompressError(err) {
+			return HTTP_ERROR_BAD_REQUEST
+		}
+		return HTTP_ERROR_READ_BODY_REQUEST_FAIL
+	}
+
+	if err := ctx.request.Body.Close(); err != nil {
+		ctx.LogError("Close request body fail: %s", err.Error())
+		return HTTP_ERROR_CLOSE_BODY_REQUEST_FAIL
+	}
+
+	ctx.requestBody = buffer.Bytes()
+	return nil
+}
+
+/*
+* shrinkBody: drop buffer of request body when it is too large to keep in pool
+ */
+func (ctx *Context) shrinkBody() {
+	if cap(ctx.requestBody) > MAX_POOLED_BODY_CAPACITY {
+		ctx.requestBody = make([]byte, 0, DEFAULT_REQUEST_BODY_CAPACITY)
+	}
+}
+
+/*
+* isMaxBytesError: check if error is returned because body is larger than limit
+ */
+func isMaxBytesError(err error) bool {
+	var maxBytesError *http.MaxBytesError
+	return errors.As(err, &maxBytesError)
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadBody_TooLarge(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"name":"test"}`))
+	ctx := &Context{rw: httptest.NewRecorder(), request: request}
+	MaxBodyBytes(5)(ctx)
+
+	if err := ctx.readBody(); err != HTTP_ERROR_REQUEST_ENTITY_TOO_LARGE {
+		t.Errorf("Expected HTTP_ERROR_REQUEST_ENTITY_TOO_LARGE, got %v", err)
+	}
+}
+
+func TestReadBody_StreamBody(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"name":"test"}`))
+	ctx := &Context{rw: httptest.NewRecorder(), request: request}
+	StreamBody()(ctx)
+
+	if err := ctx.readBody(); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	if len(ctx.requestBody) != 0 {
+		t.Errorf("Expected body is not read, got %s", string(ctx.requestBody))
+	}
+
+	body, err := io.ReadAll(ctx.Body())
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	if string(body) != `{"name":"test"}` {
+		t.Errorf("Expected {\"name\":\"test\"}, got %s", string(body))
+	}
+}
+
+func TestShrinkBody(t *testing.T) {
+	ctx := &Context{requestBody: make([]byte, MAX_POOLED_BODY_CAPACITY+1)}
+	ctx.shrinkBody()
+
+	if cap(ctx.requestBody) != DEFAULT_REQUEST_BODY_CAPACITY {
+		t.Errorf("Expected capacity %d, got %d", DEFAULT_REQUEST_BODY_CAPACITY, cap(ctx.requestBody))
+	}
+}
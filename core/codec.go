@@ -0,0 +1,249 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	XML_CONTENT_TYPE           = "application/xml"
+	TEXT_XML_CONTENT_TYPE      = "text/xml"
+	MSGPACK_CONTENT_TYPE       = "application/msgpack"
+	X_MSGPACK_CONTENT_TYPE     = "application/x-msgpack"
+	PROTOBUF_CONTENT_TYPE      = "application/protobuf"
+	X_PROTOBUF_CONTENT_TYPE    = "application/x-protobuf"
+	ACCEPT_KEY                 = "Accept"
+	MEDIA_TYPE_ANY             = "*/*"
+	MEDIA_TYPE_JSON_SUFFIX     = "+json"
+	MEDIA_TYPE_XML_SUFFIX      = "+xml"
+	MEDIA_TYPE_WILDCARD_SUFFIX = "/*"
+	XHTML_CONTENT_TYPE         = "application/xhtml+xml"
+)
+
+var errNotProtoMessage = errors.New("value is not a proto message")
+
+/*
+* Codec: encode and decode request, response body for a media type
+ */
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+/*
+* codecs: registry of codecs by media type
+* The order of media types is used to choose codec when Accept header is a wildcard
+ */
+var codecs = map[string]Codec{
+	JSON_CONTENT_TYPE:       jsonCodec{},
+	XML_CONTENT_TYPE:        xmlCodec{},
+	TEXT_XML_CONTENT_TYPE:   xmlCodec{},
+	MSGPACK_CONTENT_TYPE:    msgpackCodec{},
+	X_MSGPACK_CONTENT_TYPE:  msgpackCodec{},
+	PROTOBUF_CONTENT_TYPE:   protobufCodec{},
+	X_PROTOBUF_CONTENT_TYPE: protobufCodec{},
+}
+var codecMediaTypes = []string{
+	JSON_CONTENT_TYPE,
+	XML_CONTENT_TYPE,
+	TEXT_XML_CONTENT_TYPE,
+	MSGPACK_CONTENT_TYPE,
+	X_MSGPACK_CONTENT_TYPE,
+	PROTOBUF_CONTENT_TYPE,
+	X_PROTOBUF_CONTENT_TYPE,
+}
+
+/*
+* RegisterCodec: register codec for a media type, it replaces old codec of the media type
+* @params: mediaType string, example: application/cbor
+* @params: codec Codec
+* @return: void
+ */
+func RegisterCodec(mediaType string, codec Codec) {
+	mediaType = strings.ToLower(mediaType)
+	if _, ok := codecs[mediaType]; !ok {
+		codecMediaTypes = append(codecMediaTypes, mediaType)
+	}
+	codecs[mediaType] = codec
+}
+
+/*
+* getCodec: get codec by media type
+* Media type with suffix +json, +xml uses json, xml codec (except application/xhtml+xml, it is html page of browser)
+* @params: mediaType string
+* @return: Codec (nil if not found)
+ */
+func getCodec(mediaType string) Codec {
+	if codec, ok := codecs[mediaType]; ok {
+		return codec
+	}
+
+	if strings.HasSuffix(mediaType, MEDIA_TYPE_JSON_SUFFIX) {
+		return codecs[JSON_CONTENT_TYPE]
+	}
+	if strings.HasSuffix(mediaType, MEDIA_TYPE_XML_SUFFIX) && mediaType != XHTML_CONTENT_TYPE {
+		return codecs[XML_CONTENT_TYPE]
+	}
+	return nil
+}
+
+/*
+* parseMediaType: get media type from Content-Type header: "application/json; charset=utf-8" => "application/json"
+ */
+func parseMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	return mediaType
+}
+
+type acceptMediaType struct {
+	mediaType string
+	quality   float64
+}
+
+/*
+* negotiateMediaType: choose media type of response from Accept header
+* Media type with suffix +json, +xml is only chosen when no registered media type or wildcard is accepted
+* @params: accept string (value of Accept header)
+* @return: string media type, blank if no media type is supported
+ */
+func negotiateMediaType(accept string) string {
+	if strings.TrimSpace(accept) == BLANK {
+		return JSON_CONTENT_TYPE
+	}
+
+	acceptTypes := []acceptMediaType{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		acceptTypes = append(acceptTypes, acceptMediaType{mediaType: mediaType, quality: quality})
+	}
+
+	sort.SliceStable(acceptTypes, func(i, j int) bool {
+		return acceptTypes[i].quality > acceptTypes[j].quality
+	})
+
+	suffixMediaType := BLANK
+	for _, acceptType := range acceptTypes {
+		if acceptType.mediaType == MEDIA_TYPE_ANY {
+			return JSON_CONTENT_TYPE
+		}
+
+		if strings.HasSuffix(acceptType.mediaType, MEDIA_TYPE_WILDCARD_SUFFIX) {
+			prefix := strings.TrimSuffix(acceptType.mediaType, "*")
+			for _, mediaType := range codecMediaTypes {
+				if strings.HasPrefix(mediaType, prefix) {
+					return mediaType
+				}
+			}
+			continue
+		}
+
+		if _, ok := codecs[acceptType.mediaType]; ok {
+			return acceptType.mediaType
+		}
+		if suffixMediaType == BLANK && getCodec(acceptType.mediaType) != nil {
+			suffixMediaType = acceptType.mediaType
+		}
+	}
+
+	return suffixMediaType
+}
+
+/*
+* jsonCodec: application/json
+ */
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+/*
+* xmlCodec: application/xml, text/xml
+ */
+type xmlCodec struct{}
+
+func (xmlCodec) Marshal(v any) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+func (xmlCodec) Unmarshal(data []byte, v any) error {
+	return xml.Unmarshal(data, v)
+}
+
+/*
+* msgpackCodec: application/msgpack, application/x-msgpack
+* Field names follow json tag, so a model is encoded with same keys as json
+ */
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	encoder := msgpack.NewEncoder(buffer)
+	encoder.SetCustomStructTag("json")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+	return decoder.Decode(v)
+}
+
+/*
+* protobufCodec: application/protobuf, application/x-protobuf
+* Request and response data must be proto messages
+* Envelope can't be encoded by protobuf, so only data of envelope is encoded
+ */
+type protobufCodec struct{}
+
+func (protobufCodec) Marshal(v any) ([]byte, error) {
+	if body, ok := v.(responseBody); ok {
+		v = body.Data
+	}
+
+	message, ok := v.(proto.Message)
+	if !ok {
+		return nil, errNotProtoMessage
+	}
+	return proto.Marshal(message)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v any) error {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return errNotProtoMessage
+	}
+	return proto.Unmarshal(data, message)
+}
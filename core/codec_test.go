@@ -0,0 +1,97 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateMediaType(t *testing.T) {
+	testCases := map[string]string{
+		BLANK:             JSON_CONTENT_TYPE,
+		"*/*":             JSON_CONTENT_TYPE,
+		"application/xml": XML_CONTENT_TYPE,
+		"text/html, application/xml;q=0.9, */*;q=0.8": XML_CONTENT_TYPE,
+		"application/json;q=0.5, application/msgpack": MSGPACK_CONTENT_TYPE,
+		"application/problem+json":                    "application/problem+json",
+		"application/problem+json, application/xml":   XML_CONTENT_TYPE,
+		"text/html, application/xhtml+xml, */*;q=0.8": JSON_CONTENT_TYPE,
+		"application/xhtml+xml":                       BLANK,
+		"text/*":                                      TEXT_XML_CONTENT_TYPE,
+		"text/html":                                   BLANK,
+		"application/xml;q=0":                         BLANK,
+	}
+
+	for accept, expected := range testCases {
+		if mediaType := negotiateMediaType(accept); mediaType != expected {
+			t.Errorf("Accept %s: expected %s, got %s", accept, expected, mediaType)
+		}
+	}
+}
+
+type codecTestModel struct {
+	Name  string `json:"name" xml:"name"`
+	Value int    `json:"value" xml:"value"`
+}
+
+func TestCodec_MarshalAndUnmarshal(t *testing.T) {
+	for _, mediaType := range []string{JSON_CONTENT_TYPE, XML_CONTENT_TYPE, MSGPACK_CONTENT_TYPE} {
+		codec := getCodec(mediaType)
+		data, err := codec.Marshal(codecTestModel{Name: "test", Value: 1})
+		if err != nil {
+			t.Fatalf("%s: marshal fail: %s", mediaType, err.Error())
+		}
+
+		model := &codecTestModel{}
+		if err := codec.Unmarshal(data, model); err != nil {
+			t.Fatalf("%s: unmarshal fail: %s", mediaType, err.Error())
+		}
+		if model.Name != "test" || model.Value != 1 {
+			t.Errorf("%s: expected {test 1}, got %+v", mediaType, *model)
+		}
+	}
+}
+
+func TestWriteSuccess_XmlAccepted(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	ctx := &Context{rw: recorder, requestID: "1", responseMediaType: XML_CONTENT_TYPE}
+
+	ctx.writeSuccess(NewDefaultHttpResponse(codecTestModel{Name: "test", Value: 1}))
+
+	if contentType := recorder.Header().Get(CONTENT_TYPE_KEY); contentType != XML_CONTENT_TYPE {
+		t.Errorf("Expected %s, got %s", XML_CONTENT_TYPE, contentType)
+	}
+	expected := `<response><code>200</code><data><name>test</name><value>1</value></data></response>`
+	if body := recorder.Body.String(); body != expected {
+		t.Errorf("Expected %s, got %s", expected, body)
+	}
+}
+
+func TestWriteSuccess_XmlMarshalFailInternalError(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	ctx := &Context{rw: recorder, requestID: "1", responseMediaType: XML_CONTENT_TYPE}
+
+	ctx.writeSuccess(NewDefaultHttpResponse(map[string]any{"name": "test"}))
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("Expected %d, got %d", http.StatusInternalServerError, recorder.Code)
+	}
+	if body := recorder.Body.String(); strings.Contains(body, `"name":"test"`) {
+		t.Errorf("Expected data is not sent in other media type, got %s", body)
+	}
+}
+
+func TestWriteError_XmlMarshalFailInternalError(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	ctx := &Context{rw: recorder, requestID: "1", responseMediaType: XML_CONTENT_TYPE}
+
+	ctx.writeError(NewHttpError(http.StatusBadRequest, 1, "invalid", map[string]any{"field": "name"}))
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("Expected %d, got %d", http.StatusInternalServerError, recorder.Code)
+	}
+	if body := recorder.Body.String(); strings.Contains(body, `"field":"name"`) {
+		t.Errorf("Expected error data is not sent in other media type, got %s", body)
+	}
+}
@@ -0,0 +1,400 @@
+package core
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	ENCODING_GZIP             = "gzip"
+	ENCODING_BROTLI           = "br"
+	ENCODING_ZSTD             = "zstd"
+	CONTENT_ENCODING_KEY      = "Content-Encoding"
+	ACCEPT_ENCODING_KEY       = "Accept-Encoding"
+	DEFAULT_COMPRESS_MIN_SIZE = 1 << 10 // 1 KB
+)
+
+var DEFAULT_COMPRESS_ENCODINGS = []string{ENCODING_ZSTD, ENCODING_BROTLI, ENCODING_GZIP}
+
+var DEFAULT_COMPRESS_CONTENT_TYPES = []string{
+	JSON_CONTENT_TYPE,
+	PROBLEM_JSON_CONTENT_TYPE,
+	XML_CONTENT_TYPE,
+	TEXT_XML_CONTENT_TYPE,
+	"application/javascript",
+	"image/svg+xml",
+	"text/plain",
+	"text/html",
+	"text/css",
+	"text/csv",
+}
+
+/*
+* compressEncoder: encoder of a content encoding, it is reset to write to another writer and reused by pool
+ */
+type compressEncoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(writer io.Writer)
+}
+
+var compressEncoderPools = map[string]*sync.Pool{
+	ENCODING_GZIP: {New: func() any {
+		encoder, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return encoder
+	}},
+	ENCODING_BROTLI: {New: func() any {
+		return brotli.NewWriterLevel(nil, brotli.DefaultCompression)
+	}},
+	ENCODING_ZSTD: {New: func() any {
+		encoder, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+		return encoder
+	}},
+}
+
+/*
+* Compress: middleware compress response by encoding that client accepts in Accept-Encoding header
+* Response is compressed if its content type is in allowlist and it is not smaller than min size
+* @params: config CompressionConfig
+* @return: Middleware
+ */
+func Compress(config CompressionConfig) Middleware {
+	encodings := config.GetEncodings()
+	contentTypes := config.GetContentTypes()
+	minSize := config.GetMinSize()
+	return func(ctx *Context) HttpError {
+		// Connection of websocket is hijacked, it is not compressed here
+		if ctx.GetRequestHeader("Upgrade") != BLANK || ctx.compressWriter != nil {
+			ctx.Next()
+			return nil
+		}
+
+		ctx.rw.Header().Add("Vary", ACCEPT_ENCODING_KEY)
+		encoding := negotiateEncoding(ctx.GetRequestHeader(ACCEPT_ENCODING_KEY), encodings)
+		if encoding == BLANK || ctx.Method == http.MethodHead {
+			ctx.Next()
+			return nil
+		}
+
+		ctx.compressWriter = &compressWriter{
+			ResponseWriter: ctx.response.ResponseWriter,
+			encoding:       encoding,
+			contentTypes:   contentTypes,
+			minSize:        minSize,
+		}
+		ctx.response.ResponseWriter = ctx.compressWriter
+		ctx.Next()
+		return nil
+	}
+}
+
+/*
+* negotiateEncoding: choose encoding that has highest quality in Accept-Encoding header
+* Encoding that is first in list of server is chosen when qualities are equal
+* @return: string (blank if client doesn't accept any encoding)
+ */
+func negotiateEncoding(header string, encodings []string) string {
+	if header == BLANK {
+		return BLANK
+	}
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = q
+			}
+		}
+		qualities[strings.ToLower(strings.TrimSpace(name))] = quality
+	}
+
+	best, bestQuality := BLANK, 0.0
+	for _, encoding := range encodings {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality, ok = qualities["*"]
+		}
+		if ok && quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return best
+}
+
+/*
+* compressWriter: compress response when it is large enough
+* Response is buffered until it reaches min size, smaller response is written without compression when it is closed
+ */
+type compressWriter struct {
+	http.ResponseWriter
+	encoding     string
+	contentTypes []string
+	minSize      int
+	status       int
+	buffer       []byte
+	encoder      compressEncoder
+	// Response is not compressed, data is written directly
+	isPassThrough bool
+}
+
+func (cw *compressWriter) WriteHeader(statusCode int) {
+	if cw.isPassThrough || cw.encoder != nil {
+		cw.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	if statusCode < http.StatusOK || statusCode == http.StatusNoContent || statusCode == http.StatusNotModified {
+		cw.isPassThrough = true
+		cw.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	if cw.status == 0 {
+		cw.status = statusCode
+	}
+}
+
+func (cw *compressWriter) Write(data []byte) (int, error) {
+	if cw.isPassThrough {
+		return cw.ResponseWriter.Write(data)
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Write(data)
+	}
+
+	if !cw.canCompress() {
+		cw.passThrough()
+		return cw.ResponseWriter.Write(data)
+	}
+	cw.buffer = append(cw.buffer, data...)
+	if len(cw.buffer) >= cw.minSize {
+		if err := cw.startCompress(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+/*
+* canCompress: check headers of response, content that is encoded or not in allowlist is not compressed
+ */
+func (cw *compressWriter) canCompress() bool {
+	header := cw.Header()
+	if header.Get(CONTENT_ENCODING_KEY) != BLANK {
+		return false
+	}
+	if length, err := strconv.Atoi(header.Get("Content-Length")); err == nil && length < cw.minSize {
+		return false
+	}
+	mediaType := parseMediaType(header.Get(CONTENT_TYPE_KEY))
+	for _, contentType := range cw.contentTypes {
+		if mediaType == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+* passThrough: write buffered header and data without compression
+ */
+func (cw *compressWriter) passThrough() error {
+	cw.isPassThrough = true
+	if cw.status != 0 {
+		cw.ResponseWriter.WriteHeader(cw.status)
+	}
+	if len(cw.buffer) == 0 {
+		return nil
+	}
+	_, err := cw.ResponseWriter.Write(cw.buffer)
+	cw.buffer = nil
+	return err
+}
+
+/*
+* isCompressed: check response with body size is compressed, it is used before response is written
+ */
+func (cw *compressWriter) isCompressed(size int) bool {
+	if cw.encoder != nil {
+		return true
+	}
+	return !cw.isPassThrough && size >= cw.minSize && cw.canCompress()
+}
+
+func (cw *compressWriter) startCompress() error {
+	header := cw.Header()
+	header.Set(CONTENT_ENCODING_KEY, cw.encoding)
+	header.Del("Content-Length")
+	// Strong etag is for uncompressed body
+	if etag := header.Get(ETAG_KEY); strings.HasPrefix(etag, `"`) {
+		header.Set(ETAG_KEY, WEAK_ETAG_PREFIX+etag)
+	}
+	if cw.status != 0 {
+		cw.ResponseWriter.WriteHeader(cw.status)
+	}
+
+	cw.encoder = compressEncoderPools[cw.encoding].Get().(compressEncoder)
+	cw.encoder.Reset(cw.ResponseWriter)
+	_, err := cw.encoder.Write(cw.buffer)
+	cw.buffer = nil
+	return err
+}
+
+/*
+* Flush: flush data to client, buffered data is compressed if it can be
+ */
+func (cw *compressWriter) Flush() {
+	if !cw.isPassThrough && cw.encoder == nil {
+		if len(cw.buffer) > 0 && cw.canCompress() {
+			cw.startCompress()
+		} else {
+			cw.passThrough()
+		}
+	}
+	if cw.encoder != nil {
+		cw.encoder.Flush()
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+/*
+* Hijack: take over connection, response is not compressed
+ */
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := cw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errHijackNotSupported
+	}
+	cw.isPassThrough = true
+	return hijacker.Hijack()
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+/*
+* close: write data that is buffered and return encoder to pool, it is called when request is done
+ */
+func (cw *compressWriter) close() error {
+	if cw.encoder == nil {
+		if cw.isPassThrough {
+			return nil
+		}
+		return cw.passThrough()
+	}
+	err := cw.encoder.Close()
+	cw.encoder.Reset(nil)
+	compressEncoderPools[cw.encoding].Put(cw.encoder)
+	cw.encoder = nil
+	return err
+}
+
+/*
+* closeCompressWriter: write rest of compressed response, it is called before finish hooks
+ */
+func (ctx *Context) closeCompressWriter() {
+	if ctx.compressWriter == nil {
+		return
+	}
+	if err := ctx.compressWriter.close(); err != nil {
+		ctx.LogError("Close compress writer fail: %s", err.Error())
+	}
+	ctx.compressWriter = nil
+}
+
+/*
+* gzipBody: decompress request body that has Content-Encoding: gzip
+* Reader of gzip is created at first read, so error of gzip header is returned by reading body
+ */
+type gzipBody struct {
+	body   io.ReadCloser
+	reader *gzip.Reader
+}
+
+var gzipReaderPool sync.Pool
+
+/*
+* errDecompressBody: body is not valid compressed data, it is a bad request
+ */
+type errDecompressBody struct {
+	err error
+}
+
+func (err errDecompressBody) Error() string {
+	return "decompress request body fail: " + err.err.Error()
+}
+
+func (err errDecompressBody) Unwrap() error {
+	return err.err
+}
+
+func (body *gzipBody) Read(data []byte) (int, error) {
+	if body.reader == nil {
+		reader, _ := gzipReaderPool.Get().(*gzip.Reader)
+		var err error
+		if reader == nil {
+			reader, err = gzip.NewReader(body.body)
+		} else {
+			err = reader.Reset(body.body)
+		}
+		if err != nil {
+			return 0, decompressError(err)
+		}
+		body.reader = reader
+	}
+	n, err := body.reader.Read(data)
+	return n, decompressError(err)
+}
+
+func (body *gzipBody) Close() error {
+	if body.reader != nil {
+		body.reader.Close()
+		gzipReaderPool.Put(body.reader)
+		body.reader = nil
+	}
+	return body.body.Close()
+}
+
+func decompressError(err error) error {
+	var corruptError flate.CorruptInputError
+	if errors.Is(err, gzip.ErrHeader) || errors.Is(err, gzip.ErrChecksum) || errors.As(err, &corruptError) {
+		return errDecompressBody{err: err}
+	}
+	return err
+}
+
+/*
+* isDecompressError: check if error is returned because request body is not valid compressed data
+ */
+func isDecompressError(err error) bool {
+	var decompressError errDecompressBody
+	return errors.As(err, &decompressError)
+}
+
+/*
+* decompressBody: replace gzip request body by decompressed body
+* Body limit is applied to decompressed body
+ */
+func decompressBody(request *http.Request) {
+	encoding := strings.ToLower(strings.TrimSpace(request.Header.Get(CONTENT_ENCODING_KEY)))
+	if encoding != ENCODING_GZIP && encoding != "x-gzip" {
+		return
+	}
+	request.Body = &gzipBody{body: request.Body}
+	request.Header.Del(CONTENT_ENCODING_KEY)
+	request.Header.Del("Content-Length")
+	request.ContentLength = -1
+}
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+type compressTestRequest struct {
+	Name string `json:"name"`
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	testCases := []struct {
+		header   string
+		expected string
+	}{
+		{header: BLANK, expected: BLANK},
+		{header: "gzip, deflate", expected: ENCODING_GZIP},
+		{header: "gzip, br, zstd", expected: ENCODING_ZSTD},
+		{header: "gzip;q=1.0, br;q=0.8", expected: ENCODING_GZIP},
+		{header: "zstd;q=0, *", expected: ENCODING_BROTLI},
+		{header: "identity", expected: BLANK},
+	}
+	for _, testCase := range testCases {
+		if encoding := negotiateEncoding(testCase.header, DEFAULT_COMPRESS_ENCODINGS); encoding != testCase.expected {
+			t.Errorf("%s: expected %s, got %s", testCase.header, testCase.expected, encoding)
+		}
+	}
+}
+
+func decodeTestBody(t *testing.T, encoding string, body []byte) string {
+	var reader io.Reader
+	var err error
+	switch encoding {
+	case ENCODING_GZIP:
+		reader, err = gzip.NewReader(bytes.NewReader(body))
+	case ENCODING_BROTLI:
+		reader = brotli.NewReader(bytes.NewReader(body))
+	case ENCODING_ZSTD:
+		reader, err = zstd.NewReader(bytes.NewReader(body))
+	default:
+		return string(body)
+	}
+	if err != nil {
+		t.Fatalf("Create %s reader fail: %v", encoding, err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Decode %s body fail: %v", encoding, err)
+	}
+	return string(data)
+}
+
+func TestCompress_Response(t *testing.T) {
+	useTestRouter(t)
+	UseMiddleware(Compress(CompressionConfig{MinSize: 100}))
+	items := strings.Repeat("item,", 100)
+	RegisterAPI("/items", http.MethodGet, func(ctx *Context, request *corsTestRequest) (HttpResponse, HttpError) {
+		return NewDefaultHttpResponse(items), nil
+	})
+	RegisterAPI("/small", http.MethodGet, func(ctx *Context, request *corsTestRequest) (HttpResponse, HttpError) {
+		return NewDefaultHttpResponse("ok"), nil
+	})
+
+	for _, encoding := range DEFAULT_COMPRESS_ENCODINGS {
+		recorder := serveTestRequest(http.MethodGet, "/items", map[string]string{ACCEPT_ENCODING_KEY: encoding})
+		if recorder.Header().Get(CONTENT_ENCODING_KEY) != encoding || recorder.Header().Get("Vary") != ACCEPT_ENCODING_KEY {
+			t.Fatalf("%s: unexpected headers %v", encoding, recorder.Header())
+		}
+		if body := decodeTestBody(t, encoding, recorder.Body.Bytes()); !strings.Contains(body, items) {
+			t.Errorf("%s: unexpected body %s", encoding, body)
+		}
+	}
+
+	recorder := serveTestRequest(http.MethodGet, "/small", map[string]string{ACCEPT_ENCODING_KEY: ENCODING_GZIP})
+	if recorder.Code != http.StatusOK || recorder.Header().Get(CONTENT_ENCODING_KEY) != BLANK || !strings.Contains(recorder.Body.String(), "ok") {
+		t.Errorf("Expected small response is not compressed, got %v %s", recorder.Header(), recorder.Body.String())
+	}
+}
+
+func TestCompressWriter_ContentTypeNotAllowed(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	cw := &compressWriter{ResponseWriter: recorder, encoding: ENCODING_GZIP, contentTypes: DEFAULT_COMPRESS_CONTENT_TYPES, minSize: 1}
+	cw.Header().Set(CONTENT_TYPE_KEY, "image/png")
+	cw.WriteHeader(http.StatusCreated)
+	cw.Write([]byte("png data"))
+	cw.close()
+
+	if recorder.Code != http.StatusCreated || recorder.Header().Get(CONTENT_ENCODING_KEY) != BLANK || recorder.Body.String() != "png data" {
+		t.Errorf("Expected image is not compressed, got %d %v", recorder.Code, recorder.Header())
+	}
+}
+
+func TestDecompressRequestBody(t *testing.T) {
+	useTestRouter(t)
+	var name string
+	RegisterAPI("/items", http.MethodPost, func(ctx *Context, request *compressTestRequest) (HttpResponse, HttpError) {
+		name = request.Name
+		return NewDefaultHttpResponse(nil), nil
+	})
+
+	buffer := bytes.Buffer{}
+	writer := gzip.NewWriter(&buffer)
+	writer.Write([]byte(`{"name":"book"}`))
+	writer.Close()
+
+	request := httptest.NewRequest(http.MethodPost, "/items", &buffer)
+	request.Header.Set(REQUEST_ID_HEADER, "test")
+	request.Header.Set(CONTENT_TYPE_KEY, JSON_CONTENT_TYPE)
+	request.Header.Set(CONTENT_ENCODING_KEY, ENCODING_GZIP)
+	recorder := httptest.NewRecorder()
+	serveHTTP(recorder, request)
+	if recorder.Code != http.StatusOK || name != "book" {
+		t.Errorf("Expected gzip body is decoded, got %d %s", recorder.Code, name)
+	}
+
+	request = httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"name":"book"}`))
+	request.Header.Set(REQUEST_ID_HEADER, "test")
+	request.Header.Set(CONTENT_TYPE_KEY, JSON_CONTENT_TYPE)
+	request.Header.Set(CONTENT_ENCODING_KEY, ENCODING_GZIP)
+	recorder = httptest.NewRecorder()
+	serveHTTP(recorder, request)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid gzip body, got %d", recorder.Code)
+	}
+}
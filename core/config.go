@@ -1,27 +1,106 @@
 package core
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
 type CoreConfig struct {
-	Debug      bool             `yaml:"debug"`
-	Server     ServerConfig     `yaml:"server"`
-	Context    ContextConfig    `yaml:"context"`
-	Database   Database         `yaml:"database"`
-	RabbitMQ   RabbitMQConfig   `yaml:"rabbitmq"`
-	Redis      RedisConfig      `yaml:"redis"`
-	Proxy      ProxyConfig      `yaml:"proxy"`
-	HttpClient HttpClientConfig `yaml:"http_client"`
-	Scheduler  SchedulerConfig  `yaml:"scheduler"`
+	Debug       bool              `yaml:"debug"`
+	Server      ServerConfig      `yaml:"server"`
+	Context     ContextConfig     `yaml:"context"`
+	Database    Database          `yaml:"database"`
+	RabbitMQ    RabbitMQConfig    `yaml:"rabbitmq"`
+	Redis       RedisConfig       `yaml:"redis"`
+	Proxy       ProxyConfig       `yaml:"proxy"`
+	HttpClient  HttpClientConfig  `yaml:"http_client"`
+	Scheduler   SchedulerConfig   `yaml:"scheduler"`
+	OpenAPI     OpenAPIConfig     `yaml:"openapi"`
+	SSE         SSEConfig         `yaml:"sse"`
+	WebSocket   WebSocketConfig   `yaml:"websocket"`
+	AccessLog   AccessLogConfig   `yaml:"access_log"`
+	RequestID   RequestIDConfig   `yaml:"request_id"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Metrics     MetricsConfig     `yaml:"metrics"`
+	Health      HealthConfig      `yaml:"health"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Cors        CorsConfig        `yaml:"cors"`
+	JWT         JWTConfig         `yaml:"jwt"`
+	Compression CompressionConfig `yaml:"compression"`
+	ETag        ETagConfig        `yaml:"etag"`
 }
 
 type ServerConfig struct {
-	Port int `yaml:"port"`
+	Port            int              `yaml:"port"`
+	MaxBodyBytes    int64            `yaml:"max_body_bytes"`   // Max bytes of request body, 0 is unlimited
+	ShutdownTimeout int              `yaml:"shutdown_timeout"` // Seconds to wait for requests and consumers when server shuts down
+	Upload          UploadConfig     `yaml:"upload"`
+	Listeners       []ListenerConfig `yaml:"listeners"`
+}
+
+/*
+* Get time to wait for requests and consumers when server shuts down, default: 30 seconds
+ */
+func (serverConfig ServerConfig) GetShutdownTimeout() time.Duration {
+	if serverConfig.ShutdownTimeout <= 0 {
+		return DEFAULT_SHUTDOWN_TIMEOUT * time.Second
+	}
+	return time.Duration(serverConfig.ShutdownTimeout) * time.Second
+}
+
+/*
+* Get listeners of server
+* Default: http at server.port and https at :8081 with server.crt, server.key
+ */
+func (serverConfig ServerConfig) GetListeners() []ListenerConfig {
+	if len(serverConfig.Listeners) > 0 {
+		return serverConfig.Listeners
+	}
+	return []ListenerConfig{
+		{Address: fmt.Sprintf(":%d", serverConfig.Port)},
+		{Address: ":8081", CertFile: "server.crt", KeyFile: "server.key", HTTP2: true},
+	}
+}
+
+type ListenerConfig struct {
+	Address           string `yaml:"address"`        // Example: ":8080", "127.0.0.1:8443"
+	Socket            string `yaml:"socket"`         // Path of unix socket, it is used instead of address
+	CertFile          string `yaml:"cert_file"`      // Tls is enabled when cert file and key file are set
+	KeyFile           string `yaml:"key_file"`       // Certificate is reloaded when cert or key file changes
+	ClientCAFile      string `yaml:"client_ca_file"` // Client certificate is required and verified by this ca (mTLS)
+	HTTP2             bool   `yaml:"http2"`          // HTTP/2 over tls
+	H2C               bool   `yaml:"h2c"`            // HTTP/2 without tls
+	ReadTimeout       int    `yaml:"read_timeout"`   // Seconds, 0 is no timeout
+	ReadHeaderTimeout int    `yaml:"read_header_timeout"`
+	WriteTimeout      int    `yaml:"write_timeout"`
+	IdleTimeout       int    `yaml:"idle_timeout"`
+}
+
+/*
+* Check if listener serves tls
+ */
+func (listenerConfig ListenerConfig) IsTLS() bool {
+	return listenerConfig.CertFile != BLANK && listenerConfig.KeyFile != BLANK
+}
+
+type UploadConfig struct {
+	MaxMemory int64 `yaml:"max_memory"` // Bytes of files are kept in memory, the rest is saved to temporary files
+	MaxSize   int64 `yaml:"max_size"`   // Max bytes of multipart body, 0 is server.max_body_bytes
+}
+
+/*
+* Get max memory to parse multipart body, default: 32 MB
+ */
+func (uploadConfig UploadConfig) GetMaxMemory() int64 {
+	if uploadConfig.MaxMemory <= 0 {
+		return DEFAULT_UPLOAD_MAX_MEMORY
+	}
+	return uploadConfig.MaxMemory
 }
 
 type ContextConfig struct {
@@ -86,6 +165,385 @@ type SchedulerConfig struct {
 	TaskTimeout         int `yaml:"task_timeout"`
 }
 
+type OpenAPIConfig struct {
+	Enable  bool   `yaml:"enable"`
+	Path    string `yaml:"path"`
+	Title   string `yaml:"title"`
+	Version string `yaml:"version"`
+}
+
+/*
+* Get path that serves api document, default: /openapi.json
+ */
+func (openAPIConfig OpenAPIConfig) GetPath() string {
+	if openAPIConfig.Path == BLANK {
+		return OPENAPI_DEFAULT_PATH
+	}
+	return openAPIConfig.Path
+}
+
+func (openAPIConfig OpenAPIConfig) GetTitle() string {
+	if openAPIConfig.Title == BLANK {
+		return "API"
+	}
+	return openAPIConfig.Title
+}
+
+func (openAPIConfig OpenAPIConfig) GetVersion() string {
+	if openAPIConfig.Version == BLANK {
+		return "1.0.0"
+	}
+	return openAPIConfig.Version
+}
+
+type SSEConfig struct {
+	HeartbeatInterval int `yaml:"heartbeat_interval"` // Seconds between heartbeat comments of event stream
+}
+
+/*
+* Get interval of heartbeat of event stream, default: 15 seconds
+ */
+func (sseConfig SSEConfig) GetHeartbeatInterval() time.Duration {
+	if sseConfig.HeartbeatInterval <= 0 {
+		return DEFAULT_SSE_HEARTBEAT_SECONDS * time.Second
+	}
+	return time.Duration(sseConfig.HeartbeatInterval) * time.Second
+}
+
+type WebSocketConfig struct {
+	ReadBufferSize  int   `yaml:"read_buffer_size"`
+	WriteBufferSize int   `yaml:"write_buffer_size"`
+	MaxMessageSize  int64 `yaml:"max_message_size"` // Max bytes of a message from client, 0 is unlimited
+	PingInterval    int   `yaml:"ping_interval"`    // Seconds
+	PongTimeout     int   `yaml:"pong_timeout"`     // Seconds
+	WriteTimeout    int   `yaml:"write_timeout"`    // Seconds
+}
+
+/*
+* Get time to wait pong from client, default: 60 seconds
+ */
+func (webSocketConfig WebSocketConfig) GetPongTimeout() time.Duration {
+	if webSocketConfig.PongTimeout <= 0 {
+		return DEFAULT_WEBSOCKET_PONG_TIMEOUT * time.Second
+	}
+	return time.Duration(webSocketConfig.PongTimeout) * time.Second
+}
+
+/*
+* Get interval of ping, default: 9/10 of pong timeout
+ */
+func (webSocketConfig WebSocketConfig) GetPingInterval() time.Duration {
+	if webSocketConfig.PingInterval <= 0 {
+		return webSocketConfig.GetPongTimeout() * 9 / 10
+	}
+	return time.Duration(webSocketConfig.PingInterval) * time.Second
+}
+
+/*
+* Get timeout of writing a message, default: 10 seconds
+ */
+func (webSocketConfig WebSocketConfig) GetWriteTimeout() time.Duration {
+	if webSocketConfig.WriteTimeout <= 0 {
+		return DEFAULT_WEBSOCKET_WRITE_TIMEOUT * time.Second
+	}
+	return time.Duration(webSocketConfig.WriteTimeout) * time.Second
+}
+
+type AccessLogConfig struct {
+	Enable bool   `yaml:"enable"`
+	Format string `yaml:"format"` // common, combined, json
+	File   string `yaml:"file"`   // Access log is written to stdout if file is not set
+}
+
+/*
+* Get format of access log, default: combined
+ */
+func (accessLogConfig AccessLogConfig) GetFormat() string {
+	if accessLogConfig.Format == BLANK {
+		return ACCESS_LOG_FORMAT_COMBINED
+	}
+	return accessLogConfig.Format
+}
+
+type RequestIDConfig struct {
+	Header string `yaml:"header"` // X-Request-Id or traceparent (W3C trace context)
+}
+
+/*
+* Get header that carries request id, default: X-Request-Id
+ */
+func (requestIDConfig RequestIDConfig) GetHeader() string {
+	if requestIDConfig.Header == BLANK {
+		return REQUEST_ID_HEADER
+	}
+	return requestIDConfig.Header
+}
+
+type TracingConfig struct {
+	Enable      bool    `yaml:"enable"`
+	Endpoint    string  `yaml:"endpoint"` // Host and port of otlp http collector
+	Insecure    bool    `yaml:"insecure"` // Export by http instead of https
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"` // Ratio of traces are sampled, from 0 to 1
+}
+
+/*
+* Get endpoint of otlp collector, default: localhost:4318
+ */
+func (tracingConfig TracingConfig) GetEndpoint() string {
+	if tracingConfig.Endpoint == BLANK {
+		return DEFAULT_OTLP_ENDPOINT
+	}
+	return tracingConfig.Endpoint
+}
+
+/*
+* Get service name of traces, default: name of executable file
+ */
+func (tracingConfig TracingConfig) GetServiceName() string {
+	if tracingConfig.ServiceName == BLANK {
+		return filepath.Base(os.Args[0])
+	}
+	return tracingConfig.ServiceName
+}
+
+/*
+* Get ratio of sampled traces, default: 1 (all traces)
+ */
+func (tracingConfig TracingConfig) GetSampleRatio() float64 {
+	if tracingConfig.SampleRatio <= 0 {
+		return 1
+	}
+	return tracingConfig.SampleRatio
+}
+
+type MetricsConfig struct {
+	Enable bool   `yaml:"enable"`
+	Path   string `yaml:"path"`
+}
+
+/*
+* Get path that serves prometheus metrics, default: /metrics
+ */
+func (metricsConfig MetricsConfig) GetPath() string {
+	if metricsConfig.Path == BLANK {
+		return METRICS_DEFAULT_PATH
+	}
+	return metricsConfig.Path
+}
+
+type HealthConfig struct {
+	Enable     bool   `yaml:"enable"`
+	HealthPath string `yaml:"health_path"`
+	ReadyPath  string `yaml:"ready_path"`
+	Timeout    int    `yaml:"timeout"` // Seconds, timeout of each check
+}
+
+/*
+* Get path of liveness endpoint, default: /healthz
+ */
+func (healthConfig HealthConfig) GetHealthPath() string {
+	if healthConfig.HealthPath == BLANK {
+		return HEALTH_DEFAULT_PATH
+	}
+	return healthConfig.HealthPath
+}
+
+/*
+* Get path of readiness endpoint, default: /readyz
+ */
+func (healthConfig HealthConfig) GetReadyPath() string {
+	if healthConfig.ReadyPath == BLANK {
+		return READY_DEFAULT_PATH
+	}
+	return healthConfig.ReadyPath
+}
+
+/*
+* Get timeout of each health check, default: 5 seconds
+ */
+func (healthConfig HealthConfig) GetTimeout() time.Duration {
+	if healthConfig.Timeout <= 0 {
+		return DEFAULT_HEALTH_CHECK_TIMEOUT * time.Second
+	}
+	return time.Duration(healthConfig.Timeout) * time.Second
+}
+
+type RateLimitConfig struct {
+	Enable bool            `yaml:"enable"`
+	Store  string          `yaml:"store"` // memory, redis
+	Rules  []RateLimitRule `yaml:"rules"`
+}
+
+type RateLimitRule struct {
+	Route  string `yaml:"route"`  // Url pattern of api, example: /items/{id}, * for all apis
+	Key    string `yaml:"key"`    // ip, api_key, route
+	Header string `yaml:"header"` // Header of api key
+	Limit  int    `yaml:"limit"`  // Requests in a period
+	Period int    `yaml:"period"` // Seconds
+	Burst  int    `yaml:"burst"`  // Max requests at once
+}
+
+/*
+* Get token bucket of rule, default: period is 1 second, burst is limit
+ */
+func (rule RateLimitRule) GetRateLimit() RateLimit {
+	return RateLimit{
+		Limit:  rule.Limit,
+		Period: time.Duration(rule.Period) * time.Second,
+		Burst:  rule.Burst,
+	}.normalize()
+}
+
+/*
+* Get key of bucket of rule, default: ip
+ */
+func (rule RateLimitRule) GetKey() RateLimitKey {
+	switch rule.Key {
+	case RATE_LIMIT_KEY_API_KEY:
+		if rule.Header == BLANK {
+			return RateLimitKeyAPIKey(DEFAULT_API_KEY_HEADER)
+		}
+		return RateLimitKeyAPIKey(rule.Header)
+	case RATE_LIMIT_KEY_ROUTE:
+		return RateLimitKeyRoute
+	default:
+		return RateLimitKeyIP
+	}
+}
+
+type CorsConfig struct {
+	Enable           bool     `yaml:"enable"`
+	AllowOrigins     []string `yaml:"allow_origins"` // Origin or pattern: https://*.example.com, * for all origins
+	AllowMethods     []string `yaml:"allow_methods"` // Methods of route are used if it is not set
+	AllowHeaders     []string `yaml:"allow_headers"`
+	ExposeHeaders    []string `yaml:"expose_headers"`
+	AllowCredentials bool     `yaml:"allow_credentials"` // Origins must be a list or patterns, * is rejected
+	MaxAge           int      `yaml:"max_age"`           // Seconds that preflight response is cached
+}
+
+/*
+* Get allowed origins, default: all origins
+ */
+func (corsConfig CorsConfig) GetAllowOrigins() []string {
+	if len(corsConfig.AllowOrigins) == 0 {
+		return []string{CORS_ALLOW_ALL_ORIGINS}
+	}
+	return corsConfig.AllowOrigins
+}
+
+type JWTConfig struct {
+	Enable              bool     `yaml:"enable"`
+	Algorithms          []string `yaml:"algorithms"`            // HS256, RS256, ES256
+	Secret              string   `yaml:"secret"`                // Secret of HS256
+	JWKSFile            string   `yaml:"jwks_file"`             // Public keys of RS256, ES256 in a file
+	JWKSURL             string   `yaml:"jwks_url"`              // Public keys of RS256, ES256 from url, it is used if file is not set
+	JWKSRefreshInterval int      `yaml:"jwks_refresh_interval"` // Seconds
+	Issuer              string   `yaml:"issuer"`
+	Audience            string   `yaml:"audience"`
+	ClockSkew           *int     `yaml:"clock_skew"` // Seconds, it is allowed when checking exp, nbf, iat, 0 disables it
+}
+
+/*
+* Get algorithms of token, default: HS256 if secret is set, RS256 and ES256 if jwks is set
+ */
+func (jwtConfig JWTConfig) GetAlgorithms() []string {
+	if len(jwtConfig.Algorithms) != 0 {
+		return jwtConfig.Algorithms
+	}
+	algorithms := []string{}
+	if jwtConfig.Secret != BLANK {
+		algorithms = append(algorithms, JWT_ALGORITHM_HS256)
+	}
+	if jwtConfig.JWKSFile != BLANK || jwtConfig.JWKSURL != BLANK {
+		algorithms = append(algorithms, JWT_ALGORITHM_RS256, JWT_ALGORITHM_ES256)
+	}
+	return algorithms
+}
+
+/*
+* Get interval to refresh jwks, default: 300 seconds
+ */
+func (jwtConfig JWTConfig) GetJWKSRefreshInterval() time.Duration {
+	if jwtConfig.JWKSRefreshInterval <= 0 {
+		return DEFAULT_JWKS_REFRESH_INTERVAL * time.Second
+	}
+	return time.Duration(jwtConfig.JWKSRefreshInterval) * time.Second
+}
+
+/*
+* Get clock skew, default: 30 seconds if it is not set
+ */
+func (jwtConfig JWTConfig) GetClockSkew() time.Duration {
+	if jwtConfig.ClockSkew == nil {
+		return DEFAULT_JWT_CLOCK_SKEW * time.Second
+	}
+	if *jwtConfig.ClockSkew <= 0 {
+		return 0
+	}
+	return time.Duration(*jwtConfig.ClockSkew) * time.Second
+}
+
+type CompressionConfig struct {
+	Enable       bool     `yaml:"enable"`
+	Encodings    []string `yaml:"encodings"`     // zstd, br, gzip in order of preference
+	MinSize      int      `yaml:"min_size"`      // Bytes, smaller response is not compressed
+	ContentTypes []string `yaml:"content_types"` // Media types of response that are compressed
+}
+
+/*
+* Get encodings in order of preference, default: zstd, br, gzip
+ */
+func (compressionConfig CompressionConfig) GetEncodings() []string {
+	if len(compressionConfig.Encodings) == 0 {
+		return DEFAULT_COMPRESS_ENCODINGS
+	}
+	encodings := make([]string, 0, len(compressionConfig.Encodings))
+	for _, encoding := range compressionConfig.Encodings {
+		if _, ok := compressEncoderPools[encoding]; ok {
+			encodings = append(encodings, encoding)
+		} else {
+			LoggerInstance.Warning("Encoding %s is not supported", encoding)
+		}
+	}
+	return encodings
+}
+
+/*
+* Get min size of compressed response, default: 1 KB
+ */
+func (compressionConfig CompressionConfig) GetMinSize() int {
+	if compressionConfig.MinSize <= 0 {
+		return DEFAULT_COMPRESS_MIN_SIZE
+	}
+	return compressionConfig.MinSize
+}
+
+/*
+* Get media types of compressed response, default: json, xml, text and javascript
+ */
+func (compressionConfig CompressionConfig) GetContentTypes() []string {
+	if len(compressionConfig.ContentTypes) == 0 {
+		return DEFAULT_COMPRESS_CONTENT_TYPES
+	}
+	return compressionConfig.ContentTypes
+}
+
+type ETagConfig struct {
+	Enable bool   `yaml:"enable"`
+	Mode   string `yaml:"mode"` // strong, weak
+}
+
+/*
+* Get mode of etag, default: strong
+ */
+func (etagConfig ETagConfig) GetMode() string {
+	if etagConfig.Mode == BLANK {
+		return ETAG_MODE_STRONG
+	}
+	return etagConfig.Mode
+}
+
 func loadConfigFile(configFile string) CoreConfig {
 	data, err := os.ReadFile(configFile)
 	if err != nil {
@@ -21,7 +21,20 @@ const (
 
 // Error code
 const (
-	ERROR_CODE_READ_BODY_REQUEST_FAIL  = 100
-	ERROR_CODE_CLOSE_BODY_REQUEST_FAIL = 101
-	ERROR_BAD_BODY_REQUEST             = 102
+	ERROR_CODE_READ_BODY_REQUEST_FAIL   = 100
+	ERROR_CODE_CLOSE_BODY_REQUEST_FAIL  = 101
+	ERROR_BAD_BODY_REQUEST              = 102
+	ERROR_BIND_REQUEST_FAIL             = 103
+	ERROR_CODE_METHOD_NOT_ALLOWED       = 104
+	ERROR_CODE_NOT_ACCEPTABLE           = 105
+	ERROR_CODE_UNSUPPORTED_MEDIA_TYPE   = 106
+	ERROR_CODE_REQUEST_ENTITY_TOO_LARGE = 107
+	ERROR_CODE_PARSE_MULTIPART_FAIL     = 108
+	ERROR_CODE_FORM_FILE_NOT_FOUND      = 109
+	ERROR_CODE_STREAMING_NOT_SUPPORTED  = 110
+	ERROR_CODE_INTERNAL_SERVER_ERROR    = 111
+	ERROR_CODE_TOO_MANY_REQUESTS        = 112
+	ERROR_CODE_UNAUTHORIZED             = 113
+	ERROR_CODE_INSUFFICIENT_SCOPE       = 114
+	ERROR_CODE_PRECONDITION_FAILED      = 115
 )
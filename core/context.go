@@ -2,8 +2,8 @@ package core
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 )
@@ -17,6 +17,7 @@ type Context struct {
 	cancelFunc    context.CancelFunc
 	URL           string
 	Method        string
+	route         string
 	Timeout       time.Duration
 	requestID     string
 	requestBody   []byte
@@ -25,6 +26,30 @@ type Context struct {
 	request       *http.Request
 	rw            http.ResponseWriter
 	isResponseEnd bool
+	pathParams    pathParams
+	allowMethods  string
+	// Cors policy of group of api, it overrides common cors middleware
+	corsPolicy *corsPolicy
+	// Claims of token that is verified by JWT middleware
+	claims   *Claims
+	envelope ResponseEnvelope
+	// Media type of response, it is chosen by Accept header
+	responseMediaType string
+	uploadLimit       int64
+	isUploadLimited   bool
+	bodyLimit         int64
+	isBodyLimited     bool
+	// Request body is not read to memory, handler reads it by ctx.Body()
+	isStreamBody bool
+	// Response writer that records status and bytes, ctx.rw points to it
+	response    responseWriter
+	finishHooks []func(ctx *Context)
+	// Values of request that are saved by ctx.Set or ContextKey
+	values contextValues
+	// Writer that compresses response, it is set by Compress middleware
+	compressWriter *compressWriter
+	// Etag of response is computed from body if it is set by ETag middleware
+	etagMode string
 }
 
 /*
@@ -45,7 +70,11 @@ func getContext() *Context {
 * @return: void
  */
 func putContext(ctx *Context) {
+	ctx.closeCompressWriter()
+	ctx.runFinishHooks()
 	ctx.cancelFunc()
+	ctx.shrinkBody()
+	ctx.resetValues()
 	httpContextPool.Put(ctx)
 }
 
@@ -58,6 +87,26 @@ func (ctx *Context) Next() {
 	ctx.isRequestEnd = false
 }
 
+/*
+* OnFinish: add function that is called when request is done, before context is returned to pool
+* Functions are called in order they are added, it is used by middlewares to work after handler
+* @params: hook func(ctx *Context)
+* @return: void
+ */
+func (ctx *Context) OnFinish(hook func(ctx *Context)) {
+	ctx.finishHooks = append(ctx.finishHooks, hook)
+}
+
+/*
+* runFinishHooks: call finish hooks and clear them
+ */
+func (ctx *Context) runFinishHooks() {
+	for _, hook := range ctx.finishHooks {
+		hook(ctx)
+	}
+	ctx.finishHooks = ctx.finishHooks[:0]
+}
+
 /*
 * GetRequestHeader: Get request header by key
 * @params: key string
@@ -67,6 +116,26 @@ func (ctx *Context) GetRequestHeader(key string) string {
 	return ctx.request.Header.Get(key)
 }
 
+/*
+* GetRoute: Get url pattern of api that handles request, example: /items/{id}
+* @return: string
+ */
+func (ctx *Context) GetRoute() string {
+	return ctx.route
+}
+
+/*
+* GetRemoteIP: Get ip address of client that sends request
+* @return: string
+ */
+func (ctx *Context) GetRemoteIP() string {
+	remoteIP, _, err := net.SplitHostPort(ctx.request.RemoteAddr)
+	if err != nil {
+		return ctx.request.RemoteAddr
+	}
+	return remoteIP
+}
+
 /*
 * GetQueryParam: Get query param by key
 * @params: key string
@@ -76,6 +145,18 @@ func (ctx *Context) GetQueryParam(key string) string {
 	return ctx.request.URL.Query().Get(key)
 }
 
+/*
+* GetPathParam: Get value of param segment in url by key
+* Example: api is registered with url /items/{id}, request url is /items/1
+* => GetPathParam("id") returns "1"
+* @params: key string
+* @return: string
+ */
+func (ctx *Context) GetPathParam(key string) string {
+	value, _ := ctx.pathParams.get(key)
+	return value
+}
+
 /*
 * ListQueryParam: Get list query param by key
 * @params: key string
@@ -87,11 +168,14 @@ func (ctx *Context) GetArrayQueryParam(key string) []string {
 
 /*
 * GetFormData: get data in body when context/type is application/x-www-form-urlencoded
-* in header request
+* or multipart/form-data in header request
 * @return string
 * if key exist in form data return value of key, otherwise return empty string
  */
 func (ctx *Context) GetFormData(key string) string {
+	if ctx.request.PostForm == nil && isMultipart(ctx.request) {
+		ctx.parseMultipartForm()
+	}
 	return ctx.request.PostForm.Get(key)
 }
 
@@ -103,55 +187,104 @@ func (ctx *Context) RedirectURL(url string) {
 	http.Redirect(ctx.rw, ctx.request, url, http.StatusSeeOther)
 }
 
+/*
+* SetResponseEnvelope: set envelope for response of this request
+* It is used in middleware to change envelope for some apis
+* @params: envelope ResponseEnvelope
+* @return: void
+ */
+func (ctx *Context) SetResponseEnvelope(envelope ResponseEnvelope) {
+	ctx.envelope = envelope
+}
+
 /*
 * writeError: write error http response to user
+* Error is written by json if it can't be encoded by the media type that user accepts
  */
 func (ctx *Context) writeError(httpErr HttpError) {
-	ctx.rw.Header().Set("Content-Type", "application/json")
+	statusCode, contentType, data := ctx.getEnvelope().Error(ctx, httpErr)
 	ctx.rw.Header().Set("Request-Id", ctx.requestID)
-	ctx.responseBody.Code = httpErr.GetCode()
-	ctx.responseBody.Message = httpErr.GetMessage()
-	ctx.responseBody.Data = httpErr.GetErrorData()
 
-	body, err := json.Marshal(ctx.responseBody)
+	mediaType, codec := ctx.getResponseCodec(contentType)
+	body, err := codec.Marshal(data)
+	// Response is not sent in other media type than user accepts, failure of codec is an internal error
 	if err != nil {
-		ctx.LogError("Marshal error json. RequestId: %s, Error: %s", ctx.requestID, err.Error())
+		ctx.LogError("Marshal error %s. RequestId: %s, Error: %s", mediaType, ctx.requestID, err.Error())
+		ctx.rw.Header().Set("Content-Type", JSON_CONTENT_TYPE)
 		ctx.endResponse(http.StatusInternalServerError, `{"code":500,"message":"Internal server error(Marshal error response data)","errorData":null,"data":null}`)
 		return
 	}
 
-	ctx.endResponse(int(httpErr.GetStatusCode()), string(body))
+	ctx.rw.Header().Set("Content-Type", mediaType)
+	ctx.endResponse(statusCode, string(body))
 }
 
 /*
 * writeSuccess: write success http response to user
  */
 func (ctx *Context) writeSuccess(httpRes HttpResponse) {
-	ctx.rw.Header().Set("Content-Type", "application/json")
+	statusCode, contentType, data := ctx.getEnvelope().Success(ctx, httpRes)
 	ctx.rw.Header().Set("Request-Id", ctx.requestID)
-	ctx.responseBody.Code = httpRes.GetReponseCode()
-	ctx.responseBody.Message = BLANK
-	ctx.responseBody.Data = httpRes.GetBody()
 
-	body, err := json.Marshal(ctx.responseBody)
+	mediaType, codec := ctx.getResponseCodec(contentType)
+	body, err := codec.Marshal(data)
+	// Response is not sent in other media type than user accepts, failure of codec is an internal error
 	if err != nil {
-		ctx.LogError("Marshal json. RequestId: %s, Error: %s", ctx.requestID, err.Error())
+		ctx.LogError("Marshal %s. RequestId: %s, Error: %s", mediaType, ctx.requestID, err.Error())
+		ctx.rw.Header().Set("Content-Type", JSON_CONTENT_TYPE)
 		ctx.endResponse(http.StatusInternalServerError, `{"code":500,"message":"Internal server error(Marshal response data)","errorData":null,"data":null}`)
 		return
 	}
 
-	ctx.endResponse(int(httpRes.GetStatusCode()), string(body))
+	ctx.rw.Header().Set("Content-Type", mediaType)
+	if ctx.isNotModified(statusCode, body) {
+		ctx.endResponse(http.StatusNotModified, BLANK)
+		return
+	}
+	ctx.endResponse(statusCode, string(body))
+}
+
+/*
+* getResponseCodec: get media type and codec to encode response
+* Media type that user accepts is used if it isn't json, otherwise content type of envelope is used
+* @params: contentType string (content type of envelope)
+* @return: string, Codec
+ */
+func (ctx *Context) getResponseCodec(contentType string) (string, Codec) {
+	if ctx.responseMediaType != BLANK && ctx.responseMediaType != JSON_CONTENT_TYPE {
+		if codec := getCodec(ctx.responseMediaType); codec != nil {
+			return ctx.responseMediaType, codec
+		}
+	}
+
+	if codec := getCodec(parseMediaType(contentType)); codec != nil {
+		return contentType, codec
+	}
+	return contentType, codecs[JSON_CONTENT_TYPE]
+}
+
+/*
+* getEnvelope: get envelope of request, global envelope is used if it is not set
+ */
+func (ctx *Context) getEnvelope() ResponseEnvelope {
+	if ctx.envelope != nil {
+		return ctx.envelope
+	}
+	return responseEnvelope
 }
 
 /*
 * endResponse: call write header if it is not called before and write body to writer
+* Body of HEAD request is not written, headers are same as GET request
  */
 func (ctx *Context) endResponse(statusCode int, body string) {
 	if !ctx.isResponseEnd {
 		ctx.isResponseEnd = true
 		// end response
 		ctx.rw.WriteHeader(statusCode)
-		fmt.Fprint(ctx.rw, body)
+		if ctx.Method != http.MethodHead {
+			fmt.Fprint(ctx.rw, body)
+		}
 	}
 }
 
@@ -163,7 +296,7 @@ func (ctx *Context) endResponse(statusCode int, body string) {
 func GetContextForTest() *Context {
 	ctx := httpContextPool.Get().(*Context)
 	ctx.Context, ctx.cancelFunc = context.WithTimeout(coreContext, contextTimeout)
-	ctx.requestID = ID.GenerateID()
+	ctx.requestID = newRequestID()
 	return ctx
 }
 
@@ -174,7 +307,7 @@ func GetContextWithTimeout(timeout time.Duration) *Context {
 	ctx := httpContextPool.Get().(*Context)
 	ctx.Context, ctx.cancelFunc = context.WithTimeout(coreContext, timeout)
 	ctx.Timeout = timeout
-	ctx.requestID = ID.GenerateID()
+	ctx.requestID = newRequestID()
 	ctx.URL = "GetContextWithTimeout"
 	return ctx
 }
@@ -183,6 +316,9 @@ func GetContextWithTimeout(timeout time.Duration) *Context {
 * Return context to http context pool
  */
 func PutContext(ctx *Context) {
+	ctx.runFinishHooks()
 	ctx.cancelFunc()
+	ctx.shrinkBody()
+	ctx.resetValues()
 	httpContextPool.Put(ctx)
 }
@@ -0,0 +1,116 @@
+package core
+
+import "sync"
+
+/*
+* contextValues: values of request that are saved by middlewares and handlers
+* They are cleared when context is returned to pool
+ */
+type contextValues struct {
+	mutex  sync.RWMutex
+	values map[any]any
+}
+
+/*
+* ContextKey: typed key of value in context, keys with same name are different keys
+* Example: var USER_KEY = core.NewContextKey[*User]("user")
+* => USER_KEY.Set(ctx, user) in middleware, user, ok := USER_KEY.Get(ctx) in handler
+ */
+type ContextKey[T any] struct {
+	key *contextKey
+}
+
+type contextKey struct {
+	name string
+}
+
+/*
+* NewContextKey: create typed key of value in context
+* @params: name string (name of key, it is used in logs)
+* @return: ContextKey[T]
+ */
+func NewContextKey[T any](name string) ContextKey[T] {
+	return ContextKey[T]{key: &contextKey{name: name}}
+}
+
+/*
+* Name: name of key
+* @return: string
+ */
+func (key ContextKey[T]) Name() string {
+	return key.key.name
+}
+
+/*
+* Set: save value of key to context
+* @params: ctx *Context, value T
+* @return: void
+ */
+func (key ContextKey[T]) Set(ctx *Context, value T) {
+	ctx.setValue(key.key, value)
+}
+
+/*
+* Get: get value of key from context
+* @params: ctx *Context
+* @return: T, bool (false if value is not set)
+ */
+func (key ContextKey[T]) Get(ctx *Context) (T, bool) {
+	value, ok := ctx.getValue(key.key)
+	result, ok := value.(T)
+	return result, ok
+}
+
+/*
+* Set: save value to context by key, value is kept until request is done
+* @params: key string, value any
+* @return: void
+ */
+func (ctx *Context) Set(key string, value any) {
+	ctx.setValue(key, value)
+}
+
+/*
+* Get: get value from context by key
+* @params: key string
+* @return: any, bool (false if value is not set)
+ */
+func (ctx *Context) Get(key string) (any, bool) {
+	return ctx.getValue(key)
+}
+
+/*
+* GetValue: get value from context by key, value is converted to type T
+* @params: ctx *Context, key string
+* @return: T, bool (false if value is not set or type of value is not T)
+ */
+func GetValue[T any](ctx *Context, key string) (T, bool) {
+	value, _ := ctx.getValue(key)
+	result, ok := value.(T)
+	return result, ok
+}
+
+func (ctx *Context) setValue(key any, value any) {
+	ctx.values.mutex.Lock()
+	defer ctx.values.mutex.Unlock()
+	if ctx.values.values == nil {
+		ctx.values.values = make(map[any]any)
+	}
+	ctx.values.values[key] = value
+}
+
+func (ctx *Context) getValue(key any) (any, bool) {
+	ctx.values.mutex.RLock()
+	defer ctx.values.mutex.RUnlock()
+	value, ok := ctx.values.values[key]
+	return value, ok
+}
+
+/*
+* resetValues: clear values of context before it is returned to pool
+ */
+func (ctx *Context) resetValues() {
+	ctx.values.mutex.Lock()
+	defer ctx.values.mutex.Unlock()
+	clear(ctx.values.values)
+}
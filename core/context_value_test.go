@@ -0,0 +1,70 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+type contextValueTestUser struct {
+	ID string
+}
+
+var contextValueTestUserKey = NewContextKey[*contextValueTestUser]("user")
+
+func TestContextValue_MiddlewareToHandler(t *testing.T) {
+	useTestRouter(t)
+	UseMiddleware(func(ctx *Context) HttpError {
+		contextValueTestUserKey.Set(ctx, &contextValueTestUser{ID: "user-1"})
+		ctx.Set("tenant", "acme")
+		ctx.Next()
+		return nil
+	})
+
+	var userID, tenant string
+	RegisterAPI("/me", http.MethodGet, func(ctx *Context, request *corsTestRequest) (HttpResponse, HttpError) {
+		if user, ok := contextValueTestUserKey.Get(ctx); ok {
+			userID = user.ID
+		}
+		tenant, _ = GetValue[string](ctx, "tenant")
+		return NewDefaultHttpResponse(nil), nil
+	})
+
+	serveTestRequest(http.MethodGet, "/me", nil)
+	if userID != "user-1" || tenant != "acme" {
+		t.Errorf("Expected values of middleware in handler, got %s %s", userID, tenant)
+	}
+}
+
+func TestContextValue_TypeMismatch(t *testing.T) {
+	ctx := &Context{}
+	ctx.Set("count", 1)
+
+	if _, ok := GetValue[string](ctx, "count"); ok {
+		t.Errorf("Expected value of other type is not returned")
+	}
+	if count, ok := GetValue[int](ctx, "count"); !ok || count != 1 {
+		t.Errorf("Expected 1, got %d", count)
+	}
+	if _, ok := ctx.Get("missing"); ok {
+		t.Errorf("Expected missing value is not found")
+	}
+
+	// Keys with same name are different keys
+	otherKey := NewContextKey[*contextValueTestUser]("user")
+	contextValueTestUserKey.Set(ctx, &contextValueTestUser{ID: "user-1"})
+	if _, ok := otherKey.Get(ctx); ok {
+		t.Errorf("Expected value of other key is not found")
+	}
+}
+
+func TestContextValue_ResetWhenPutContext(t *testing.T) {
+	ctx := &Context{}
+	ctx.Context, ctx.cancelFunc = context.WithCancel(context.Background())
+	ctx.Set("tenant", "acme")
+	putContext(ctx)
+
+	if _, ok := ctx.Get("tenant"); ok {
+		t.Errorf("Expected values are cleared when context is returned to pool")
+	}
+}
@@ -0,0 +1,215 @@
+package core
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	DEFAULT_CORS_ALLOW_METHODS = "POST, GET, OPTIONS, PUT, DELETE"
+	DEFAULT_CORS_ALLOW_HEADERS = "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization"
+	CORS_ALLOW_ALL_ORIGINS     = "*"
+)
+
+/*
+* corsPolicy: cors config that is prepared to handle requests
+ */
+type corsPolicy struct {
+	allowAllOrigins  bool
+	origins          map[string]bool
+	originPatterns   []*regexp.Regexp
+	allowMethods     string
+	allowHeaders     string
+	exposeHeaders    string
+	allowCredentials bool
+	maxAge           string
+}
+
+/*
+* newCorsPolicy: prepare cors config
+* Origin has * is a pattern, * matches a part of host: https://*.example.com
+ */
+func newCorsPolicy(config CorsConfig) *corsPolicy {
+	policy := &corsPolicy{
+		origins:          make(map[string]bool),
+		allowMethods:     strings.Join(config.AllowMethods, ", "),
+		allowHeaders:     strings.Join(config.AllowHeaders, ", "),
+		exposeHeaders:    strings.Join(config.ExposeHeaders, ", "),
+		allowCredentials: config.AllowCredentials,
+	}
+	if policy.allowHeaders == BLANK {
+		policy.allowHeaders = DEFAULT_CORS_ALLOW_HEADERS
+	}
+	if config.MaxAge > 0 {
+		policy.maxAge = strconv.Itoa(config.MaxAge)
+	}
+
+	for _, origin := range config.GetAllowOrigins() {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		if origin == CORS_ALLOW_ALL_ORIGINS {
+			policy.allowAllOrigins = true
+		} else if strings.Contains(origin, "*") {
+			pattern := strings.ReplaceAll(regexp.QuoteMeta(origin), `\*`, `[a-z0-9\-.]+`)
+			policy.originPatterns = append(policy.originPatterns, regexp.MustCompile("^"+pattern+"$"))
+		} else {
+			policy.origins[origin] = true
+		}
+	}
+	// Any site could read response with cookies of user, credentials require list or patterns of origins
+	if policy.allowAllOrigins && policy.allowCredentials {
+		LoggerInstance.Panic("Cors allows credentials with all origins (*), allow origins must be a list or patterns")
+	}
+	return policy
+}
+
+/*
+* isAllowedOrigin: check origin is in list or matches a pattern of policy
+ */
+func (policy *corsPolicy) isAllowedOrigin(origin string) bool {
+	if policy.allowAllOrigins {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if policy.origins[origin] {
+		return true
+	}
+	for _, pattern := range policy.originPatterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+* handle: set cors headers of response, preflight request is answered with 204
+* Origin is echoed back if it is allowed, * is sent when all origins are allowed (credentials are not allowed then)
+* @return bool: true if request is ended
+ */
+func (policy *corsPolicy) handle(ctx *Context) bool {
+	header := ctx.rw.Header()
+	origin := ctx.GetRequestHeader("Origin")
+	if !policy.allowAllOrigins {
+		header.Add("Vary", "Origin")
+	}
+	if origin == BLANK || !policy.isAllowedOrigin(origin) {
+		return false
+	}
+
+	if policy.allowAllOrigins {
+		header.Set("Access-Control-Allow-Origin", CORS_ALLOW_ALL_ORIGINS)
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if policy.allowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	// Preflight request: browser asks for method and headers of actual request
+	if ctx.Method == http.MethodOptions && ctx.GetRequestHeader("Access-Control-Request-Method") != BLANK {
+		allowMethods := policy.allowMethods
+		if allowMethods == BLANK {
+			allowMethods = DEFAULT_CORS_ALLOW_METHODS
+			if ctx.allowMethods != BLANK {
+				allowMethods = ctx.allowMethods
+			}
+		}
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		header.Set("Access-Control-Allow-Methods", allowMethods)
+		header.Set("Access-Control-Allow-Headers", policy.allowHeaders)
+		if policy.maxAge != BLANK {
+			header.Set("Access-Control-Max-Age", policy.maxAge)
+		}
+		ctx.endResponse(http.StatusNoContent, BLANK)
+		return true
+	}
+
+	if policy.exposeHeaders != BLANK {
+		header.Set("Access-Control-Expose-Headers", policy.exposeHeaders)
+	}
+	return false
+}
+
+/*
+* Cors: middleware set cors headers by config
+* Policy of group of api is used instead if it is set by RouteGroup.SetCors
+* @params: config CorsConfig
+* @return: Middleware
+ */
+func Cors(config CorsConfig) Middleware {
+	policy := newCorsPolicy(config)
+	return func(ctx *Context) HttpError {
+		// Policy of group is applied after common middlewares
+		if ctx.corsPolicy == nil && policy.handle(ctx) {
+			return nil
+		}
+		ctx.Next()
+		return nil
+	}
+}
+
+/*
+* middleware: middleware apply cors policy of group of api
+ */
+func (policy *corsPolicy) middleware() Middleware {
+	return func(ctx *Context) HttpError {
+		if policy.handle(ctx) {
+			return nil
+		}
+		ctx.Next()
+		return nil
+	}
+}
+
+/*
+* UseCors: use cors middleware for all apis
+* @params: config CorsConfig
+* @return: void
+ */
+func UseCors(config CorsConfig) {
+	UseMiddleware(Cors(config))
+}
+
+/*
+* UserCorsMiddleware: use cors middleware that allows all origins
+* @return: void
+ */
+func UserCorsMiddleware() {
+	UseCors(CorsConfig{AllowOrigins: []string{CORS_ALLOW_ALL_ORIGINS}})
+}
+
+/*
+* SetCors: use cors policy for apis of group and its child groups, it overrides common cors middleware
+* It must be called before apis of group are registered
+* @params: config CorsConfig
+* @return: void
+ */
+func (group *RouteGroup) SetCors(config CorsConfig) {
+	group.cors = newCorsPolicy(config)
+}
+
+/*
+* getCors: get cors policy of group, policy of nearest parent group is used if group has no policy
+ */
+func (group *RouteGroup) getCors() *corsPolicy {
+	if group == nil {
+		return nil
+	}
+	if group.cors != nil {
+		return group.cors
+	}
+	return group.parent.getCors()
+}
+
+/*
+* findCorsPolicy: get cors policy of route that preflight request asks for
+ */
+func (node *routeNode) findCorsPolicy(request *http.Request) *corsPolicy {
+	if route := node.findRoute(request.Header.Get("Access-Control-Request-Method")); route != nil {
+		return route.info.cors
+	}
+	return nil
+}
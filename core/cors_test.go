@@ -0,0 +1,136 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator"
+)
+
+type corsTestRequest struct{}
+
+/*
+* useTestRouter: use an empty router, apis are registered and served by serveHTTP in test
+ */
+func useTestRouter(t *testing.T) {
+	oldRouteMap, oldRouteTree, oldMiddlewares, oldCoreContext := routeMap, routeTree, commonMiddlewares, coreContext
+	routeMap = make(map[string][]Route)
+	routeTree = newRouteNode(BLANK, segmentKind_Static)
+	commonMiddlewares = nil
+	if coreContext == nil {
+		coreContext = &Context{Context: context.Background()}
+	}
+	if contextTimeout == 0 {
+		contextTimeout = 5 * time.Second
+	}
+	httpContextPool = sync.Pool{New: func() interface{} { return &Context{} }}
+	validate = validator.New()
+	t.Cleanup(func() {
+		routeMap, routeTree, commonMiddlewares, coreContext = oldRouteMap, oldRouteTree, oldMiddlewares, oldCoreContext
+	})
+}
+
+func serveTestRequest(method string, url string, headers map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, url, nil)
+	request.Header.Set(REQUEST_ID_HEADER, "test")
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	recorder := httptest.NewRecorder()
+	serveHTTP(recorder, request)
+	return recorder
+}
+
+func corsTestHandler(ctx *Context, request *corsTestRequest) (HttpResponse, HttpError) {
+	return NewDefaultHttpResponse(map[string]string{"status": "ok"}), nil
+}
+
+func TestCors_AllowedOrigins(t *testing.T) {
+	useTestRouter(t)
+	UseCors(CorsConfig{
+		AllowOrigins:     []string{"https://app.example.com", "https://*.example.org"},
+		ExposeHeaders:    []string{REQUEST_ID_HEADER},
+		AllowCredentials: true,
+	})
+	RegisterAPI("/items", http.MethodGet, corsTestHandler)
+
+	testCases := []struct {
+		origin  string
+		allowed bool
+	}{
+		{origin: "https://app.example.com", allowed: true},
+		{origin: "https://a.b.example.org", allowed: true},
+		{origin: "https://example.org", allowed: false},
+		{origin: "https://app.example.com.evil.com", allowed: false},
+	}
+	for _, testCase := range testCases {
+		recorder := serveTestRequest(http.MethodGet, "/items", map[string]string{"Origin": testCase.origin})
+		header := recorder.Header()
+		if recorder.Code != http.StatusOK || header.Get("Vary") != "Origin" {
+			t.Errorf("%s: expected 200 with Vary: Origin, got %d %v", testCase.origin, recorder.Code, header)
+		}
+		if allowed := header.Get("Access-Control-Allow-Origin") == testCase.origin; allowed != testCase.allowed {
+			t.Errorf("%s: expected allowed %t, got %v", testCase.origin, testCase.allowed, header)
+		}
+		if testCase.allowed && (header.Get("Access-Control-Allow-Credentials") != "true" || header.Get("Access-Control-Expose-Headers") != REQUEST_ID_HEADER) {
+			t.Errorf("%s: expected credentials and exposed headers, got %v", testCase.origin, header)
+		}
+	}
+}
+
+func TestCors_Preflight(t *testing.T) {
+	useTestRouter(t)
+	UseCors(CorsConfig{AllowOrigins: []string{CORS_ALLOW_ALL_ORIGINS}, MaxAge: 600})
+	RegisterAPI("/items", http.MethodGet, corsTestHandler)
+	RegisterAPI("/items", http.MethodPost, corsTestHandler)
+
+	recorder := serveTestRequest(http.MethodOptions, "/items", map[string]string{
+		"Origin":                        "https://app.example.com",
+		"Access-Control-Request-Method": http.MethodPost,
+	})
+	header := recorder.Header()
+	if recorder.Code != http.StatusNoContent || header.Get("Access-Control-Allow-Origin") != CORS_ALLOW_ALL_ORIGINS {
+		t.Fatalf("Expected 204 with *, got %d %v", recorder.Code, header)
+	}
+	if header.Get("Access-Control-Allow-Methods") != "GET, POST, HEAD, OPTIONS" || header.Get("Access-Control-Max-Age") != "600" {
+		t.Errorf("Unexpected preflight headers: %v", header)
+	}
+}
+
+func TestCors_GroupOverride(t *testing.T) {
+	useTestRouter(t)
+	UseCors(CorsConfig{AllowOrigins: []string{"https://app.example.com"}})
+	RegisterAPI("/items", http.MethodGet, corsTestHandler)
+	admin := Group("/admin")
+	admin.SetCors(CorsConfig{AllowOrigins: []string{"https://admin.example.com"}, AllowMethods: []string{http.MethodPost}})
+	RegisterGroupAPI(admin, "/items", http.MethodPost, corsTestHandler)
+
+	headers := map[string]string{"Origin": "https://admin.example.com", "Access-Control-Request-Method": http.MethodPost}
+	recorder := serveTestRequest(http.MethodOptions, "/admin/items", headers)
+	if recorder.Header().Get("Access-Control-Allow-Origin") != "https://admin.example.com" || recorder.Header().Get("Access-Control-Allow-Methods") != http.MethodPost {
+		t.Errorf("Expected policy of group for preflight, got %v", recorder.Header())
+	}
+
+	recorder = serveTestRequest(http.MethodPost, "/admin/items", map[string]string{"Origin": "https://app.example.com"})
+	if recorder.Header().Get("Access-Control-Allow-Origin") != BLANK {
+		t.Errorf("Expected origin of common policy is not allowed in group, got %v", recorder.Header())
+	}
+
+	recorder = serveTestRequest(http.MethodGet, "/items", map[string]string{"Origin": "https://admin.example.com"})
+	if recorder.Header().Get("Access-Control-Allow-Origin") != BLANK {
+		t.Errorf("Expected origin of group is not allowed outside group, got %v", recorder.Header())
+	}
+}
+
+func TestCors_CredentialsWithAllOriginsRejected(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic for credentials with all origins")
+		}
+	}()
+	newCorsPolicy(CorsConfig{AllowOrigins: []string{CORS_ALLOW_ALL_ORIGINS}, AllowCredentials: true})
+}
@@ -0,0 +1,197 @@
+package core
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+)
+
+const PROBLEM_JSON_CONTENT_TYPE = "application/problem+json"
+
+/*
+* ResponseEnvelope: strategy to wrap response data and error before writing to user
+* Default envelope is {"code", "message", "data"}
+ */
+type ResponseEnvelope interface {
+	/*
+	* Success: build body of success response
+	* @return: status code, content type, body
+	 */
+	Success(ctx *Context, httpRes HttpResponse) (int, string, any)
+
+	/*
+	* Error: build body of error response
+	* @return: status code, content type, body
+	 */
+	Error(ctx *Context, httpErr HttpError) (int, string, any)
+}
+
+/*
+* EnvelopeSchema: content types and schemas of bodies that an envelope writes, it is used to build api document
+ */
+type EnvelopeSchema struct {
+	SuccessContentType string
+	Success            *OpenAPISchema
+	ErrorContentType   string
+	Error              *OpenAPISchema
+}
+
+/*
+* ResponseEnvelopeSchema: optional interface of ResponseEnvelope to describe its bodies in api document
+* Bodies of envelope that doesn't implement it are described as any json
+ */
+type ResponseEnvelopeSchema interface {
+	/*
+	* Schema: describe success and error body
+	* @params: dataSchema *OpenAPISchema (schema of response data of api)
+	* @return: EnvelopeSchema
+	 */
+	Schema(dataSchema *OpenAPISchema) EnvelopeSchema
+}
+
+var responseEnvelope ResponseEnvelope = DefaultEnvelope{}
+
+/*
+* SetResponseEnvelope: set envelope for all apis
+* Use ctx.SetResponseEnvelope in middleware to set envelope for some apis
+* @params: envelope ResponseEnvelope
+* @return: void
+ */
+func SetResponseEnvelope(envelope ResponseEnvelope) {
+	responseEnvelope = envelope
+}
+
+/*
+* DefaultEnvelope: {"code": 200, "message": "", "data": {...}}
+ */
+type DefaultEnvelope struct{}
+
+func (DefaultEnvelope) Success(ctx *Context, httpRes HttpResponse) (int, string, any) {
+	ctx.responseBody.Code = httpRes.GetReponseCode()
+	ctx.responseBody.Message = BLANK
+	ctx.responseBody.Data = httpRes.GetBody()
+	return httpRes.GetStatusCode(), JSON_CONTENT_TYPE, ctx.responseBody
+}
+
+func (DefaultEnvelope) Error(ctx *Context, httpErr HttpError) (int, string, any) {
+	ctx.responseBody.Code = httpErr.GetCode()
+	ctx.responseBody.Message = httpErr.GetMessage()
+	ctx.responseBody.Data = httpErr.GetErrorData()
+	return httpErr.GetStatusCode(), JSON_CONTENT_TYPE, ctx.responseBody
+}
+
+func (DefaultEnvelope) Schema(dataSchema *OpenAPISchema) EnvelopeSchema {
+	return EnvelopeSchema{
+		SuccessContentType: JSON_CONTENT_TYPE,
+		Success: &OpenAPISchema{
+			Type: "object",
+			Properties: map[string]*OpenAPISchema{
+				"code":    {Type: "integer"},
+				"message": {Type: "string"},
+				"data":    dataSchema,
+			},
+			Required: []string{"code", "data"},
+		},
+		ErrorContentType: JSON_CONTENT_TYPE,
+		Error: &OpenAPISchema{
+			Type: "object",
+			Properties: map[string]*OpenAPISchema{
+				"code":    {Type: "integer", Description: "Error code"},
+				"message": {Type: "string"},
+				"data":    {Description: "Error data"},
+			},
+			Required: []string{"code"},
+		},
+	}
+}
+
+/*
+* BareEnvelope: write response data without wrapper
+* Error is written as DefaultEnvelope
+ */
+type BareEnvelope struct{}
+
+func (BareEnvelope) Success(ctx *Context, httpRes HttpResponse) (int, string, any) {
+	return httpRes.GetStatusCode(), JSON_CONTENT_TYPE, httpRes.GetBody()
+}
+
+func (BareEnvelope) Error(ctx *Context, httpErr HttpError) (int, string, any) {
+	return DefaultEnvelope{}.Error(ctx, httpErr)
+}
+
+func (BareEnvelope) Schema(dataSchema *OpenAPISchema) EnvelopeSchema {
+	schema := DefaultEnvelope{}.Schema(dataSchema)
+	schema.Success = dataSchema
+	return schema
+}
+
+/*
+* ProblemDetails: error body follow RFC 7807
+ */
+type ProblemDetails struct {
+	XMLName  xml.Name `json:"-" xml:"problem"`
+	Type     string   `json:"type" xml:"type"`
+	Title    string   `json:"title" xml:"title"`
+	Status   int      `json:"status" xml:"status"`
+	Detail   string   `json:"detail,omitempty" xml:"detail,omitempty"`
+	Instance string   `json:"instance,omitempty" xml:"instance,omitempty"`
+	Code     int      `json:"code" xml:"code"`
+	Data     any      `json:"data,omitempty" xml:"data,omitempty"`
+}
+
+/*
+* ProblemEnvelope: write response data without wrapper
+* and write error as problem document (RFC 7807)
+ */
+type ProblemEnvelope struct {
+	// TypeURI: prefix of type of problem, type = TypeURI + code. If it is blank, type is "about:blank"
+	TypeURI string
+}
+
+func (ProblemEnvelope) Success(ctx *Context, httpRes HttpResponse) (int, string, any) {
+	return httpRes.GetStatusCode(), JSON_CONTENT_TYPE, httpRes.GetBody()
+}
+
+func (envelope ProblemEnvelope) Error(ctx *Context, httpErr HttpError) (int, string, any) {
+	statusCode := httpErr.GetStatusCode()
+	// Problem document always describes an error status
+	if statusCode < http.StatusBadRequest {
+		statusCode = http.StatusBadRequest
+	}
+
+	problemType := "about:blank"
+	if envelope.TypeURI != BLANK {
+		problemType = envelope.TypeURI + strconv.Itoa(httpErr.GetCode())
+	}
+
+	return statusCode, PROBLEM_JSON_CONTENT_TYPE, ProblemDetails{
+		Type:     problemType,
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Detail:   httpErr.GetMessage(),
+		Instance: ctx.URL,
+		Code:     httpErr.GetCode(),
+		Data:     httpErr.GetErrorData(),
+	}
+}
+
+func (ProblemEnvelope) Schema(dataSchema *OpenAPISchema) EnvelopeSchema {
+	return EnvelopeSchema{
+		SuccessContentType: JSON_CONTENT_TYPE,
+		Success:            dataSchema,
+		ErrorContentType:   PROBLEM_JSON_CONTENT_TYPE,
+		Error: &OpenAPISchema{
+			Type: "object",
+			Properties: map[string]*OpenAPISchema{
+				"type":     {Type: "string", Format: "uri-reference"},
+				"title":    {Type: "string"},
+				"status":   {Type: "integer"},
+				"detail":   {Type: "string"},
+				"instance": {Type: "string"},
+				"code":     {Type: "integer", Description: "Error code"},
+				"data":     {Description: "Error data"},
+			},
+			Required: []string{"type", "title", "status", "code"},
+		},
+	}
+}
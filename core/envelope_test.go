@@ -0,0 +1,62 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteSuccess_DefaultEnvelope(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	ctx := &Context{rw: recorder, requestID: "1"}
+
+	ctx.writeSuccess(NewDefaultHttpResponse(map[string]string{"name": "test"}))
+
+	if body := recorder.Body.String(); body != `{"code":200,"data":{"name":"test"}}` {
+		t.Errorf("Expected default envelope, got %s", body)
+	}
+}
+
+func TestWriteSuccess_BareEnvelope(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	ctx := &Context{rw: recorder, requestID: "1"}
+	ctx.SetResponseEnvelope(BareEnvelope{})
+
+	ctx.writeSuccess(NewDefaultHttpResponse(map[string]string{"name": "test"}))
+
+	if body := recorder.Body.String(); body != `{"name":"test"}` {
+		t.Errorf("Expected bare json, got %s", body)
+	}
+}
+
+func TestWriteError_ProblemEnvelope(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	ctx := &Context{rw: recorder, requestID: "1", URL: "/items/1"}
+	ctx.SetResponseEnvelope(ProblemEnvelope{TypeURI: "https://example.com/errors/"})
+
+	ctx.writeError(NewDefaultHttpError(1001, "Item not found"))
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", recorder.Code)
+	}
+	if contentType := recorder.Header().Get(CONTENT_TYPE_KEY); contentType != PROBLEM_JSON_CONTENT_TYPE {
+		t.Errorf("Expected %s, got %s", PROBLEM_JSON_CONTENT_TYPE, contentType)
+	}
+
+	problem := ProblemDetails{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Unmarshal problem fail: %s", err.Error())
+	}
+	expected := ProblemDetails{
+		Type:     "https://example.com/errors/1001",
+		Title:    "Bad Request",
+		Status:   http.StatusBadRequest,
+		Detail:   "Item not found",
+		Instance: "/items/1",
+		Code:     1001,
+	}
+	if problem != expected {
+		t.Errorf("Expected %+v, got %+v", expected, problem)
+	}
+}
@@ -63,4 +63,6 @@ var (
 	ERROR_TASK_ALREADY_EXISTED                  Error = NewError(26, "Task has already existed")
 	ERROR_REMOVE_OLD_TASK_FAIL                  Error = NewError(27, "Remove old task failed!")
 	ERROR_TASK_IS_EXPIRED                       Error = NewError(28, "Task is expired!")
+	ERROR_ROUTE_PATTERN_INVALID                 Error = NewError(29, "Route pattern is invalid")
+	ERROR_ROUTE_CONFLICT                        Error = NewError(30, "Route conflict with a registered route")
 )
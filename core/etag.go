@@ -0,0 +1,163 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	ETAG_KEY                = "ETag"
+	LAST_MODIFIED_KEY       = "Last-Modified"
+	IF_NONE_MATCH_KEY       = "If-None-Match"
+	IF_MODIFIED_SINCE_KEY   = "If-Modified-Since"
+	IF_MATCH_KEY            = "If-Match"
+	IF_UNMODIFIED_SINCE_KEY = "If-Unmodified-Since"
+	ETAG_MODE_STRONG        = "strong"
+	ETAG_MODE_WEAK          = "weak"
+	WEAK_ETAG_PREFIX        = "W/"
+)
+
+/*
+* ETag: middleware compute etag of response body of GET api
+* Strong etag is changed when any byte of body is changed, weak etag is used when body is equivalent but not same,
+* for example: it is compressed
+* Request with If-None-Match or If-Modified-Since is answered with 304 when response is not modified
+* @params: mode string (ETAG_MODE_STRONG, ETAG_MODE_WEAK)
+* @return: Middleware
+ */
+func ETag(mode string) Middleware {
+	if mode != ETAG_MODE_STRONG && mode != ETAG_MODE_WEAK {
+		LoggerInstance.Warning("Etag mode %s is not supported, use %s", mode, ETAG_MODE_STRONG)
+		mode = ETAG_MODE_STRONG
+	}
+	return func(ctx *Context) HttpError {
+		ctx.etagMode = mode
+		ctx.Next()
+		return nil
+	}
+}
+
+/*
+* SetETag: set etag of response, it is used instead of etag that is computed from body
+* @params: value string (value of etag without quotes), weak bool
+* @return: void
+ */
+func (ctx *Context) SetETag(value string, weak bool) {
+	etag := `"` + value + `"`
+	if weak {
+		etag = WEAK_ETAG_PREFIX + etag
+	}
+	ctx.rw.Header().Set(ETAG_KEY, etag)
+}
+
+/*
+* SetLastModified: set time that resource is modified, it is compared with If-Modified-Since
+* @params: modifiedTime time.Time
+* @return: void
+ */
+func (ctx *Context) SetLastModified(modifiedTime time.Time) {
+	ctx.rw.Header().Set(LAST_MODIFIED_KEY, modifiedTime.UTC().Format(http.TimeFormat))
+}
+
+/*
+* CheckPrecondition: check If-Match and If-Unmodified-Since of request before resource is updated
+* Handler calls it with current etag and modified time of resource, request is rejected if resource is changed
+* @params: etag string (current etag with quotes, blank if resource doesn't exist), modifiedTime time.Time (zero if it is unknown)
+* @return: HttpError (412 if precondition fails)
+ */
+func (ctx *Context) CheckPrecondition(etag string, modifiedTime time.Time) HttpError {
+	if ifMatch := ctx.GetRequestHeader(IF_MATCH_KEY); ifMatch != BLANK {
+		if !matchETag(ifMatch, etag, false) {
+			return HTTP_ERROR_PRECONDITION_FAILED
+		}
+		return nil
+	}
+
+	if ifUnmodifiedSince := ctx.GetRequestHeader(IF_UNMODIFIED_SINCE_KEY); ifUnmodifiedSince != BLANK && !modifiedTime.IsZero() {
+		since, err := http.ParseTime(ifUnmodifiedSince)
+		if err == nil && modifiedTime.Truncate(time.Second).After(since) {
+			return HTTP_ERROR_PRECONDITION_FAILED
+		}
+	}
+	return nil
+}
+
+/*
+* isNotModified: set etag of response and check conditional headers of GET request
+* @params: statusCode int, body []byte (serialized response)
+* @return: bool (true if response is not modified, 304 is written instead)
+ */
+func (ctx *Context) isNotModified(statusCode int, body []byte) bool {
+	if statusCode != http.StatusOK || (ctx.Method != http.MethodGet && ctx.Method != http.MethodHead) {
+		return false
+	}
+
+	header := ctx.rw.Header()
+	etag := header.Get(ETAG_KEY)
+	if etag == BLANK && ctx.etagMode != BLANK {
+		etag = computeETag(body, ctx.etagMode == ETAG_MODE_WEAK)
+		header.Set(ETAG_KEY, etag)
+	}
+	// Compressed response has weak etag, 304 response must send the same etag
+	if strings.HasPrefix(etag, `"`) && ctx.compressWriter != nil && ctx.compressWriter.isCompressed(len(body)) {
+		etag = WEAK_ETAG_PREFIX + etag
+		header.Set(ETAG_KEY, etag)
+	}
+
+	// If-Modified-Since is ignored when If-None-Match is sent
+	if ifNoneMatch := ctx.GetRequestHeader(IF_NONE_MATCH_KEY); ifNoneMatch != BLANK {
+		return etag != BLANK && matchETag(ifNoneMatch, etag, true)
+	}
+	ifModifiedSince := ctx.GetRequestHeader(IF_MODIFIED_SINCE_KEY)
+	lastModified := header.Get(LAST_MODIFIED_KEY)
+	if ifModifiedSince == BLANK || lastModified == BLANK {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	modifiedTime, err := http.ParseTime(lastModified)
+	return err == nil && !modifiedTime.After(since)
+}
+
+/*
+* computeETag: etag is first 128 bits of sha256 of body
+ */
+func computeETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return WEAK_ETAG_PREFIX + etag
+	}
+	return etag
+}
+
+/*
+* matchETag: check etag is in list of etags of header, * matches any existing resource
+* Weak comparison ignores W/ prefix, strong comparison doesn't match weak etags
+ */
+func matchETag(header string, etag string, weak bool) bool {
+	if etag == BLANK {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	if !weak && strings.HasPrefix(etag, WEAK_ETAG_PREFIX) {
+		return false
+	}
+	for _, value := range strings.Split(header, ",") {
+		value = strings.TrimSpace(value)
+		if !weak && strings.HasPrefix(value, WEAK_ETAG_PREFIX) {
+			continue
+		}
+		if strings.TrimPrefix(value, WEAK_ETAG_PREFIX) == strings.TrimPrefix(etag, WEAK_ETAG_PREFIX) {
+			return true
+		}
+	}
+	return false
+}
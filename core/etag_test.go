@@ -0,0 +1,139 @@
+package core
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchETag(t *testing.T) {
+	testCases := []struct {
+		header   string
+		etag     string
+		weak     bool
+		expected bool
+	}{
+		{header: `"a"`, etag: `"a"`, weak: false, expected: true},
+		{header: `"b", "a"`, etag: `"a"`, weak: false, expected: true},
+		{header: `W/"a"`, etag: `"a"`, weak: false, expected: false},
+		{header: `W/"a"`, etag: `"a"`, weak: true, expected: true},
+		{header: `"a"`, etag: `W/"a"`, weak: true, expected: true},
+		{header: `"a"`, etag: `W/"a"`, weak: false, expected: false},
+		{header: "*", etag: `"a"`, weak: false, expected: true},
+		{header: "*", etag: BLANK, weak: false, expected: false},
+	}
+	for _, testCase := range testCases {
+		if matched := matchETag(testCase.header, testCase.etag, testCase.weak); matched != testCase.expected {
+			t.Errorf("matchETag(%s, %s, %t): expected %t", testCase.header, testCase.etag, testCase.weak, testCase.expected)
+		}
+	}
+}
+
+func TestETag_NotModified(t *testing.T) {
+	useTestRouter(t)
+	UseMiddleware(ETag(ETAG_MODE_STRONG))
+	RegisterAPI("/items", http.MethodGet, func(ctx *Context, request *corsTestRequest) (HttpResponse, HttpError) {
+		return NewDefaultHttpResponse([]string{"a", "b"}), nil
+	})
+
+	recorder := serveTestRequest(http.MethodGet, "/items", nil)
+	etag := recorder.Header().Get(ETAG_KEY)
+	if recorder.Code != http.StatusOK || len(etag) != 34 {
+		t.Fatalf("Expected 200 with strong etag, got %d %s", recorder.Code, etag)
+	}
+
+	recorder = serveTestRequest(http.MethodGet, "/items", map[string]string{IF_NONE_MATCH_KEY: etag})
+	if recorder.Code != http.StatusNotModified || recorder.Body.Len() != 0 || recorder.Header().Get(ETAG_KEY) != etag {
+		t.Errorf("Expected 304 without body, got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = serveTestRequest(http.MethodGet, "/items", map[string]string{IF_NONE_MATCH_KEY: `"other"`})
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected 200 for other etag, got %d", recorder.Code)
+	}
+}
+
+func TestETag_HandlerSetsETagAndLastModified(t *testing.T) {
+	useTestRouter(t)
+	modifiedTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	RegisterAPI("/items/{id}", http.MethodGet, func(ctx *Context, request *corsTestRequest) (HttpResponse, HttpError) {
+		ctx.SetETag("v2", true)
+		ctx.SetLastModified(modifiedTime)
+		return NewDefaultHttpResponse("item"), nil
+	})
+
+	recorder := serveTestRequest(http.MethodGet, "/items/1", map[string]string{IF_NONE_MATCH_KEY: `W/"v2"`})
+	if recorder.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for etag of handler, got %d", recorder.Code)
+	}
+
+	recorder = serveTestRequest(http.MethodGet, "/items/1", map[string]string{IF_MODIFIED_SINCE_KEY: modifiedTime.Format(http.TimeFormat)})
+	if recorder.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for If-Modified-Since, got %d", recorder.Code)
+	}
+
+	recorder = serveTestRequest(http.MethodGet, "/items/1", map[string]string{IF_MODIFIED_SINCE_KEY: modifiedTime.Add(-time.Hour).Format(http.TimeFormat)})
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected 200 when resource is modified after If-Modified-Since, got %d", recorder.Code)
+	}
+}
+
+func TestETag_IfMatchPrecondition(t *testing.T) {
+	useTestRouter(t)
+	currentETag := `"v2"`
+	RegisterAPI("/items/{id}", http.MethodPut, func(ctx *Context, request *corsTestRequest) (HttpResponse, HttpError) {
+		if err := ctx.CheckPrecondition(currentETag, time.Time{}); err != nil {
+			return nil, err
+		}
+		return NewDefaultHttpResponse("updated"), nil
+	})
+
+	testCases := map[string]int{
+		`"v2"`:   http.StatusOK,
+		`"v1"`:   http.StatusPreconditionFailed,
+		`W/"v2"`: http.StatusPreconditionFailed,
+		"*":      http.StatusOK,
+	}
+	for ifMatch, expected := range testCases {
+		if recorder := serveTestRequest(http.MethodPut, "/items/1", map[string]string{IF_MATCH_KEY: ifMatch}); recorder.Code != expected {
+			t.Errorf("If-Match %s: expected %d, got %d", ifMatch, expected, recorder.Code)
+		}
+	}
+}
+
+func TestETag_CompressedResponse(t *testing.T) {
+	useTestRouter(t)
+	UseMiddleware(Compress(CompressionConfig{MinSize: 100}))
+	UseMiddleware(ETag(ETAG_MODE_STRONG))
+	items := strings.Repeat("item,", 100)
+	RegisterAPI("/items", http.MethodGet, func(ctx *Context, request *corsTestRequest) (HttpResponse, HttpError) {
+		return NewDefaultHttpResponse(items), nil
+	})
+
+	headers := map[string]string{ACCEPT_ENCODING_KEY: ENCODING_GZIP}
+	recorder := serveTestRequest(http.MethodGet, "/items", headers)
+	etag := recorder.Header().Get(ETAG_KEY)
+	if recorder.Header().Get(CONTENT_ENCODING_KEY) != ENCODING_GZIP || !strings.HasPrefix(etag, WEAK_ETAG_PREFIX) {
+		t.Fatalf("Expected compressed response with weak etag, got %v", recorder.Header())
+	}
+
+	headers[IF_NONE_MATCH_KEY] = etag
+	recorder = serveTestRequest(http.MethodGet, "/items", headers)
+	if recorder.Code != http.StatusNotModified || recorder.Header().Get(ETAG_KEY) != etag {
+		t.Errorf("Expected 304 with etag %s, got %d %s", etag, recorder.Code, recorder.Header().Get(ETAG_KEY))
+	}
+}
+
+func TestETag_ErrorResponseIsNotChecked(t *testing.T) {
+	useTestRouter(t)
+	UseMiddleware(ETag(ETAG_MODE_STRONG))
+	RegisterAPI("/items", http.MethodGet, func(ctx *Context, request *corsTestRequest) (HttpResponse, HttpError) {
+		return nil, NewHttpError(http.StatusOK, ERROR_CODE_INTERNAL_SERVER_ERROR, "Item is deleted", nil)
+	})
+
+	recorder := serveTestRequest(http.MethodGet, "/items", map[string]string{IF_NONE_MATCH_KEY: "*"})
+	if recorder.Code == http.StatusNotModified || recorder.Header().Get(ETAG_KEY) != BLANK {
+		t.Errorf("Expected error response is not checked, got %d %v", recorder.Code, recorder.Header())
+	}
+}
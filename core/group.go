@@ -0,0 +1,128 @@
+package core
+
+import "strings"
+
+/*
+* RouteGroup: group of apis have same url prefix and middlewares
+* Middlewares of group are called after common middlewares
+* and before middlewares of api, parent group middlewares are called first
+ */
+type RouteGroup struct {
+	prefix      string
+	parent      *RouteGroup
+	middlewares []Middleware
+	cors        *corsPolicy
+}
+
+/*
+* Group: create a new route group
+* @param prefix: url prefix of all apis in group, example: /v1
+* @param middlewares: middlewares of group
+* @return *RouteGroup
+ */
+func Group(prefix string, middlewares ...Middleware) *RouteGroup {
+	return &RouteGroup{
+		prefix:      joinPath(BLANK, prefix),
+		middlewares: middlewares,
+	}
+}
+
+/*
+* Group: create a child group, it inherits prefix and middlewares from parent
+* @param prefix: url prefix after prefix of parent
+* @param middlewares: middlewares of child group
+* @return *RouteGroup
+ */
+func (group *RouteGroup) Group(prefix string, middlewares ...Middleware) *RouteGroup {
+	return &RouteGroup{
+		prefix:      joinPath(group.prefix, prefix),
+		parent:      group,
+		middlewares: middlewares,
+	}
+}
+
+/*
+* Use: add middlewares to group
+* They are applied for all apis in group and its child groups
+* @param middlewares: middlewares
+* @return void
+ */
+func (group *RouteGroup) Use(middlewares ...Middleware) {
+	group.middlewares = append(group.middlewares, middlewares...)
+}
+
+/*
+* Prefix: get full url prefix of group
+* @return string
+ */
+func (group *RouteGroup) Prefix() string {
+	return group.prefix
+}
+
+/*
+* getMiddlewares: get middlewares of group, include middlewares of parent groups
+* @return []Middleware
+ */
+func (group *RouteGroup) getMiddlewares() []Middleware {
+	if group == nil {
+		return nil
+	}
+
+	return append(group.parent.getMiddlewares(), group.middlewares...)
+}
+
+/*
+* getUrl: get full url of api in group
+* @param url: url of api
+* @return string
+ */
+func (group *RouteGroup) getUrl(url string) string {
+	if group == nil {
+		return url
+	}
+
+	return joinPath(group.prefix, url)
+}
+
+/*
+* RegisterGroupAPI: register api to a group
+* Url of api is prefix of group + url
+* @param group: group of api
+* @param url: url of api
+* @param method: method of api
+* @param handler: handler of api
+* @param middlewares: middlewares of api
+* @return void
+ */
+func RegisterGroupAPI[T any](group *RouteGroup, url string, method string, handler Handler[T], middlewares ...Middleware) {
+	registerAPI(group, url, method, handler, middlewares)
+}
+
+/*
+* RegisterGroupTypedAPI: register api with typed response to a group
+* @param group: group of api
+* @param url: url of api
+* @param method: method of api
+* @param handler: handler of api
+* @param middlewares: middlewares of api
+* @return void
+ */
+func RegisterGroupTypedAPI[Req any, Res any](group *RouteGroup, url string, method string, handler TypedHandler[Req, Res], middlewares ...Middleware) {
+	registerTypedAPI(group, url, method, handler, middlewares)
+}
+
+/*
+* joinPath: join prefix and url
+* joinPath("/v1/", "/users") => "/v1/users", joinPath("/v1", "") => "/v1"
+ */
+func joinPath(prefix string, url string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if url == BLANK {
+		if prefix == BLANK {
+			return "/"
+		}
+		return prefix
+	}
+
+	return prefix + "/" + strings.TrimPrefix(url, "/")
+}
@@ -0,0 +1,53 @@
+package core
+
+import "testing"
+
+func TestJoinPath(t *testing.T) {
+	testCases := []struct {
+		prefix   string
+		url      string
+		expected string
+	}{
+		{prefix: BLANK, url: "/v1", expected: "/v1"},
+		{prefix: BLANK, url: "v1", expected: "/v1"},
+		{prefix: "/v1", url: "/users", expected: "/v1/users"},
+		{prefix: "/v1/", url: "/users/{id}", expected: "/v1/users/{id}"},
+		{prefix: "/v1", url: BLANK, expected: "/v1"},
+		{prefix: BLANK, url: BLANK, expected: "/"},
+	}
+
+	for _, testCase := range testCases {
+		if url := joinPath(testCase.prefix, testCase.url); url != testCase.expected {
+			t.Errorf("joinPath(%s, %s): expected %s, got %s", testCase.prefix, testCase.url, testCase.expected, url)
+		}
+	}
+}
+
+func TestRouteGroup_NestedGroup(t *testing.T) {
+	calls := []string{}
+	newMiddleware := func(name string) Middleware {
+		return func(ctx *Context) HttpError {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	v1 := Group("/v1", newMiddleware("v1"))
+	admin := v1.Group("/admin", newMiddleware("admin"))
+	admin.Use(newMiddleware("admin_2"))
+
+	if url := admin.getUrl("/users"); url != "/v1/admin/users" {
+		t.Errorf("Expected /v1/admin/users, got %s", url)
+	}
+
+	for _, middleware := range admin.getMiddlewares() {
+		middleware(nil)
+	}
+	if len(calls) != 3 || calls[0] != "v1" || calls[1] != "admin" || calls[2] != "admin_2" {
+		t.Errorf("Expected [v1 admin admin_2], got %v", calls)
+	}
+
+	if middlewares := v1.getMiddlewares(); len(middlewares) != 1 {
+		t.Errorf("Expected 1 middleware in parent group, got %d", len(middlewares))
+	}
+}
@@ -0,0 +1,183 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	HEALTH_DEFAULT_PATH          = "/healthz"
+	READY_DEFAULT_PATH           = "/readyz"
+	DEFAULT_HEALTH_CHECK_TIMEOUT = 5 // Seconds
+	HEALTH_STATUS_UP             = "up"
+	HEALTH_STATUS_DOWN           = "down"
+)
+
+var errRabbitMQConnectionClosed = errors.New("connection to rabbitmq is closed")
+
+/*
+* HealthCheck: check a dependency of service, it returns error when dependency is not reachable
+ */
+type HealthCheck func(ctx *Context) error
+
+type healthCheck struct {
+	name  string
+	check HealthCheck
+}
+
+var healthCheckMutex sync.RWMutex
+var healthChecks []healthCheck
+
+/*
+* isReady: readiness gate, it is true when Init is done and false when server shuts down
+ */
+var isReady atomic.Bool
+
+type healthReport struct {
+	Status string                       `json:"status"`
+	Ready  bool                         `json:"ready"`
+	Checks map[string]healthCheckResult `json:"checks,omitempty"`
+}
+
+type healthCheckResult struct {
+	Status  string  `json:"status"`
+	Latency float64 `json:"latency_ms"`
+	Error   string  `json:"error,omitempty"`
+}
+
+/*
+* RegisterHealthCheck: add check to readiness endpoint
+* Check that has same name is replaced
+* @params: name string, check HealthCheck
+* @return: void
+ */
+func RegisterHealthCheck(name string, check HealthCheck) {
+	healthCheckMutex.Lock()
+	defer healthCheckMutex.Unlock()
+	for i := range healthChecks {
+		if healthChecks[i].name == name {
+			healthChecks[i].check = check
+			return
+		}
+	}
+	healthChecks = append(healthChecks, healthCheck{name: name, check: check})
+}
+
+/*
+* builtinHealthChecks: checks of sqlite, redis and rabbitmq which are connected
+ */
+func builtinHealthChecks() []healthCheck {
+	checks := make([]healthCheck, 0, 3)
+	if sqliteSession.DB != nil {
+		checks = append(checks, healthCheck{name: "sqlite", check: func(ctx *Context) error {
+			return sqliteSession.PingContext(ctx)
+		}})
+	}
+	if redisClient.Client != nil {
+		checks = append(checks, healthCheck{name: "redis", check: func(ctx *Context) error {
+			return redisClient.Ping(ctx).Err()
+		}})
+	}
+	if rabbitMQClient != nil {
+		checks = append(checks, healthCheck{name: "rabbitmq", check: func(ctx *Context) error {
+			if rabbitMQClient.connection == nil || rabbitMQClient.connection.IsClosed() {
+				return errRabbitMQConnectionClosed
+			}
+			return nil
+		}})
+	}
+	return checks
+}
+
+/*
+* checkHealth: run all checks in parallel, each check is canceled after timeout in config
+* @return: healthReport (status is down if a check fails)
+ */
+func checkHealth(ctx context.Context) healthReport {
+	healthCheckMutex.RLock()
+	checks := append(builtinHealthChecks(), healthChecks...)
+	healthCheckMutex.RUnlock()
+
+	report := healthReport{
+		Status: HEALTH_STATUS_UP,
+		Ready:  isReady.Load(),
+		Checks: make(map[string]healthCheckResult, len(checks)),
+	}
+	mutex := sync.Mutex{}
+	wait := sync.WaitGroup{}
+	for _, item := range checks {
+		wait.Add(1)
+		go func(item healthCheck) {
+			defer wait.Done()
+			result := runHealthCheck(ctx, item)
+			mutex.Lock()
+			defer mutex.Unlock()
+			report.Checks[item.name] = result
+			if result.Status == HEALTH_STATUS_DOWN {
+				report.Status = HEALTH_STATUS_DOWN
+			}
+		}(item)
+	}
+	wait.Wait()
+	return report
+}
+
+func runHealthCheck(ctx context.Context, item healthCheck) (result healthCheckResult) {
+	checkContext, cancel := context.WithTimeout(ctx, Config.Health.GetTimeout())
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		result.Latency = float64(time.Since(start).Microseconds()) / 1000
+		if recovered := recover(); recovered != nil {
+			LoggerInstance.Error("Health check %s panic: %v", item.name, recovered)
+			result.Status = HEALTH_STATUS_DOWN
+			result.Error = "panic"
+		}
+	}()
+
+	if err := item.check(&Context{Context: checkContext}); err != nil {
+		return healthCheckResult{Status: HEALTH_STATUS_DOWN, Error: err.Error()}
+	}
+	return healthCheckResult{Status: HEALTH_STATUS_UP}
+}
+
+/*
+* registerHealthRoutes: serve liveness and readiness of service at paths in config
+* Liveness is up while process serves requests, it doesn't run checks, so a down dependency doesn't restart the process
+* Readiness is down when a check fails, before Init is done and when server shuts down
+ */
+func registerHealthRoutes() {
+	registerRoute(Config.Health.GetHealthPath(), http.MethodGet, func(writer http.ResponseWriter, request *http.Request, params pathParams) {
+		writeHealthReport(writer, healthReport{Status: HEALTH_STATUS_UP, Ready: isReady.Load()}, true)
+	}, nil)
+
+	registerRoute(Config.Health.GetReadyPath(), http.MethodGet, func(writer http.ResponseWriter, request *http.Request, params pathParams) {
+		report := checkHealth(request.Context())
+		if !report.Ready {
+			report.Status = HEALTH_STATUS_DOWN
+		}
+		writeHealthReport(writer, report, report.Status == HEALTH_STATUS_UP)
+	}, nil)
+}
+
+func writeHealthReport(writer http.ResponseWriter, report healthReport, ok bool) {
+	body, err := json.Marshal(report)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Set(CONTENT_TYPE_KEY, JSON_CONTENT_TYPE)
+	writer.Header().Set("Cache-Control", "no-store")
+	if ok {
+		writer.WriteHeader(http.StatusOK)
+	} else {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}
+	writer.Write(body)
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func useTestHealthRoutes(t *testing.T) {
+	oldRouteMap, oldRouteTree, oldChecks := routeMap, routeTree, healthChecks
+	routeMap = make(map[string][]Route)
+	routeTree = newRouteNode(BLANK, segmentKind_Static)
+	healthChecks = nil
+	t.Cleanup(func() {
+		routeMap, routeTree, healthChecks = oldRouteMap, oldRouteTree, oldChecks
+		isReady.Store(false)
+	})
+	registerHealthRoutes()
+}
+
+func getHealth(t *testing.T, path string) (int, healthReport) {
+	recorder := httptest.NewRecorder()
+	serveHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	report := healthReport{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Expected json report, got %s", recorder.Body.String())
+	}
+	return recorder.Code, report
+}
+
+func TestHealth_AllChecksUp(t *testing.T) {
+	useTestHealthRoutes(t)
+	RegisterHealthCheck("cache", func(ctx *Context) error { return nil })
+	isReady.Store(true)
+
+	status, report := getHealth(t, READY_DEFAULT_PATH)
+	if status != http.StatusOK || report.Status != HEALTH_STATUS_UP {
+		t.Fatalf("Expected 200 up, got %d %s", status, report.Status)
+	}
+	if result, ok := report.Checks["cache"]; !ok || result.Status != HEALTH_STATUS_UP {
+		t.Errorf("Expected cache check is up, got %+v", report.Checks)
+	}
+
+	if status, _ := getHealth(t, HEALTH_DEFAULT_PATH); status != http.StatusOK {
+		t.Errorf("Expected 200, got %d", status)
+	}
+}
+
+func TestHealth_CheckFails(t *testing.T) {
+	useTestHealthRoutes(t)
+	RegisterHealthCheck("cache", func(ctx *Context) error { return nil })
+	RegisterHealthCheck("payment", func(ctx *Context) error { return errors.New("unreachable") })
+	isReady.Store(true)
+
+	status, report := getHealth(t, READY_DEFAULT_PATH)
+	if status != http.StatusServiceUnavailable || report.Status != HEALTH_STATUS_DOWN {
+		t.Fatalf("Expected 503 down, got %d %s", status, report.Status)
+	}
+	if result := report.Checks["payment"]; result.Error != "unreachable" {
+		t.Errorf("Expected error of payment check, got %+v", result)
+	}
+	if result := report.Checks["cache"]; result.Status != HEALTH_STATUS_UP {
+		t.Errorf("Expected cache check is up, got %+v", result)
+	}
+
+	status, report = getHealth(t, HEALTH_DEFAULT_PATH)
+	if status != http.StatusOK || report.Status != HEALTH_STATUS_UP || len(report.Checks) != 0 {
+		t.Errorf("Expected liveness is 200 without checks, got %d %+v", status, report)
+	}
+}
+
+func TestHealth_NotReadyBeforeInit(t *testing.T) {
+	useTestHealthRoutes(t)
+	isReady.Store(false)
+
+	if status, _ := getHealth(t, HEALTH_DEFAULT_PATH); status != http.StatusOK {
+		t.Errorf("Expected health is 200, got %d", status)
+	}
+	status, report := getHealth(t, READY_DEFAULT_PATH)
+	if status != http.StatusServiceUnavailable || report.Ready {
+		t.Errorf("Expected 503 not ready, got %d %t", status, report.Ready)
+	}
+}
+
+func TestHealth_PanicCheck(t *testing.T) {
+	useTestHealthRoutes(t)
+	RegisterHealthCheck("broken", func(ctx *Context) error { panic("nil map") })
+
+	status, report := getHealth(t, READY_DEFAULT_PATH)
+	if status != http.StatusServiceUnavailable || report.Checks["broken"].Status != HEALTH_STATUS_DOWN {
+		t.Errorf("Expected 503 with broken check down, got %d %+v", status, report.Checks)
+	}
+}
@@ -272,6 +272,9 @@ func (builder *httpClientBuilder) Request(response any) (HttpClientResponse, Err
 		}
 	}
 
+	// Propagate request id of context to other service
+	builder.ctx.propagateRequestID(req.Header)
+
 	//Set Form Data
 	if builder.formData != nil {
 		req.Header.Add(CONTENT_TYPE_KEY, FORMDATA_CONTENT_TYPE)
@@ -368,7 +371,11 @@ func (builder *httpClientBuilder) resetBuilder() {
 func (builder *httpClientBuilder) request(req *http.Request, response any) (HttpClientResponse, Error) {
 	builder.ctx.LogInfo("HttpRequest: url = %s, method = %s, header: %#v, queries: %#v, body: %#v", builder.url, builder.method, builder.headers, builder.queries, builder.body)
 	// Send http request
+	span := startClientSpan(builder.ctx, req)
+	start := time.Now()
 	resp, err := builder.Do(req)
+	endClientSpan(span, resp, err)
+	observeClientRequest(req, resp, err, start)
 	if err != nil {
 		builder.ctx.LogError("Cannot send http request: url = %s, method = %s, err = %s", builder.url, builder.method, err.Error())
 		return nil, ERROR_SEND_HTTP_REQUEST_FAIL
@@ -68,7 +68,19 @@ func (err httpError) GetErrorData() any {
 }
 
 var (
-	HTTP_ERROR_READ_BODY_REQUEST_FAIL  = NewHttpError(http.StatusInternalServerError, ERROR_CODE_READ_BODY_REQUEST_FAIL, "Read body request fail", nil)
-	HTTP_ERROR_BAD_REQUEST             = NewHttpError(http.StatusBadRequest, ERROR_CODE_READ_BODY_REQUEST_FAIL, "Read body request fail", nil)
-	HTTP_ERROR_CLOSE_BODY_REQUEST_FAIL = NewHttpError(http.StatusInternalServerError, ERROR_CODE_CLOSE_BODY_REQUEST_FAIL, "Close body request fail", nil)
+	HTTP_ERROR_READ_BODY_REQUEST_FAIL   = NewHttpError(http.StatusInternalServerError, ERROR_CODE_READ_BODY_REQUEST_FAIL, "Read body request fail", nil)
+	HTTP_ERROR_BAD_REQUEST              = NewHttpError(http.StatusBadRequest, ERROR_CODE_READ_BODY_REQUEST_FAIL, "Read body request fail", nil)
+	HTTP_ERROR_CLOSE_BODY_REQUEST_FAIL  = NewHttpError(http.StatusInternalServerError, ERROR_CODE_CLOSE_BODY_REQUEST_FAIL, "Close body request fail", nil)
+	HTTP_ERROR_METHOD_NOT_ALLOWED       = NewHttpError(http.StatusMethodNotAllowed, ERROR_CODE_METHOD_NOT_ALLOWED, "Method not allowed", nil)
+	HTTP_ERROR_NOT_ACCEPTABLE           = NewHttpError(http.StatusNotAcceptable, ERROR_CODE_NOT_ACCEPTABLE, "Not acceptable", nil)
+	HTTP_ERROR_UNSUPPORTED_MEDIA_TYPE   = NewHttpError(http.StatusUnsupportedMediaType, ERROR_CODE_UNSUPPORTED_MEDIA_TYPE, "Unsupported media type", nil)
+	HTTP_ERROR_REQUEST_ENTITY_TOO_LARGE = NewHttpError(http.StatusRequestEntityTooLarge, ERROR_CODE_REQUEST_ENTITY_TOO_LARGE, "Request entity too large", nil)
+	HTTP_ERROR_PARSE_MULTIPART_FAIL     = NewHttpError(http.StatusBadRequest, ERROR_CODE_PARSE_MULTIPART_FAIL, "Parse multipart body fail", nil)
+	HTTP_ERROR_FORM_FILE_NOT_FOUND      = NewHttpError(http.StatusBadRequest, ERROR_CODE_FORM_FILE_NOT_FOUND, "Form file is not found", nil)
+	HTTP_ERROR_STREAMING_NOT_SUPPORTED  = NewHttpError(http.StatusInternalServerError, ERROR_CODE_STREAMING_NOT_SUPPORTED, "Streaming is not supported", nil)
+	HTTP_ERROR_INTERNAL_SERVER_ERROR    = NewHttpError(http.StatusInternalServerError, ERROR_CODE_INTERNAL_SERVER_ERROR, "Internal server error", nil)
+	HTTP_ERROR_TOO_MANY_REQUESTS        = NewHttpError(http.StatusTooManyRequests, ERROR_CODE_TOO_MANY_REQUESTS, "Too many requests", nil)
+	HTTP_ERROR_UNAUTHORIZED             = NewHttpError(http.StatusUnauthorized, ERROR_CODE_UNAUTHORIZED, "Unauthorized", nil)
+	HTTP_ERROR_INSUFFICIENT_SCOPE       = NewHttpError(http.StatusForbidden, ERROR_CODE_INSUFFICIENT_SCOPE, "Insufficient scope", nil)
+	HTTP_ERROR_PRECONDITION_FAILED      = NewHttpError(http.StatusPreconditionFailed, ERROR_CODE_PRECONDITION_FAILED, "Precondition failed", nil)
 )
@@ -1,6 +1,9 @@
 package core
 
-import "net/http"
+import (
+	"encoding/xml"
+	"net/http"
+)
 
 type HttpResponse interface {
 	GetStatusCode() int
@@ -43,7 +46,8 @@ func NewHttpResponse(responseCode int, body any) HttpResponse {
 }
 
 type responseBody struct {
-	Code    int    `json:"code"`
-	Message string `json:"message,omitempty"`
-	Data    any    `json:"data"`
+	XMLName xml.Name `json:"-" xml:"response"`
+	Code    int      `json:"code" xml:"code"`
+	Message string   `json:"message,omitempty" xml:"message,omitempty"`
+	Data    any      `json:"data" xml:"data"`
 }
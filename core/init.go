@@ -2,9 +2,6 @@ package core
 
 import (
 	"context"
-	"fmt"
-	"log"
-	"net/http"
 	"sync"
 	"time"
 
@@ -14,6 +11,7 @@ import (
 var sqliteSession dbSession
 var LoggerInstance Logger
 var routeMap map[string][]Route
+var routeTree *routeNode
 var httpContextPool sync.Pool
 var commonMiddlewares []Middleware
 var Config CoreConfig
@@ -22,6 +20,7 @@ var rabbitMQClient *messageQueue
 var coreContext *Context
 var validate *validator.Validate
 var contextTimeout time.Duration
+var releaseOnce sync.Once
 
 func Init(configFile string) {
 	// Init core context
@@ -59,10 +58,11 @@ func Init(configFile string) {
 	coreContext.requestID = ID.GenerateID()
 
 	routeMap = make(map[string][]Route)
+	routeTree = newRouteNode(BLANK, segmentKind_Static)
 	httpContextPool = sync.Pool{
 		New: func() interface{} {
 			return &Context{
-				requestBody: make([]byte, 16384),
+				requestBody: make([]byte, 0, DEFAULT_REQUEST_BODY_CAPACITY),
 			}
 		},
 	}
@@ -70,6 +70,37 @@ func Init(configFile string) {
 	commonMiddlewares = make([]Middleware, 0)
 	validate = validator.New()
 
+	if Config.Tracing.Enable {
+		initTracing()
+	}
+
+	// Access log is the first common middleware, so it measures all other middlewares
+	if Config.AccessLog.Enable {
+		initAccessLog()
+	}
+
+	if Config.Compression.Enable {
+		UseMiddleware(Compress(Config.Compression))
+	}
+
+	if Config.ETag.Enable {
+		UseMiddleware(ETag(Config.ETag.GetMode()))
+	}
+
+	if Config.Cors.Enable {
+		UseCors(Config.Cors)
+	}
+
+	if Config.RateLimit.Enable {
+		initRateLimit()
+	}
+
+	if Config.JWT.Enable {
+		UseMiddleware(JWT(Config.JWT))
+	}
+
+	// All connections are opened, service is ready to receive requests
+	isReady.Store(true)
 }
 
 /*
@@ -77,15 +108,20 @@ func Init(configFile string) {
 * @return void
  */
 func Release() {
-	closeLogger()
-	closeDB()
-	releaseCacheDB()
-	releaseMessageQueue()
-	stopScheduler()
+	releaseOnce.Do(func() {
+		stopScheduler(context.Background())
+		closeDB()
+		releaseCacheDB()
+		releaseMessageQueue()
+		shutdownTracing()
+		closeLogger()
+	})
 }
 
 func closeDB() {
-	sqliteSession.Close()
+	if sqliteSession.DB != nil {
+		sqliteSession.Close()
+	}
 }
 
 func closeLogger() {
@@ -93,44 +129,14 @@ func closeLogger() {
 }
 
 func releaseCacheDB() {
-	redisClient.Close()
+	if redisClient.Client != nil {
+		redisClient.Close()
+	}
 }
 
 func releaseMessageQueue() {
-	rabbitMQClient.connection.Close()
-}
-
-/*
-* Start: Start server
-* Register all routes and listen to port
-* @return void
- */
-func Start() {
-	// Register all routes
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		routeList := routeMap[r.URL.Path]
-		for _, route := range routeList {
-			if route.Method == r.Method || r.Method == http.MethodOptions {
-				route.handler(w, r)
-				return
-			}
-		}
-		http.NotFound(w, r)
-	})
-
-	// Listen and serve
-
-	go func() {
-		LoggerInstance.Info("Start server at port: %d", Config.Server.Port)
-		err := http.ListenAndServe(fmt.Sprintf(":%d", Config.Server.Port), nil)
-		if err != nil {
-			LoggerInstance.Error("ListenAndServe fail: ", err)
-		}
-	}()
-	LoggerInstance.Info("Start server at port: 8081")
-	err := http.ListenAndServeTLS(":8081", "server.crt", "server.key", nil)
-	if err != nil {
-		log.Fatalln("ListenAndServeTLS fail: ", err)
+	if rabbitMQClient != nil {
+		rabbitMQClient.connection.Close()
 	}
 }
 
@@ -0,0 +1,503 @@
+package core
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	JWT_ALGORITHM_HS256           = "HS256"
+	JWT_ALGORITHM_RS256           = "RS256"
+	JWT_ALGORITHM_ES256           = "ES256"
+	AUTHORIZATION_HEADER          = "Authorization"
+	BEARER_PREFIX                 = "Bearer "
+	DEFAULT_JWKS_REFRESH_INTERVAL = 300 // Seconds
+	DEFAULT_JWT_CLOCK_SKEW        = 30  // Seconds
+	// Unknown key id refreshes key set at most once in this time, so invalid tokens can't flood key server
+	JWKS_MIN_REFRESH_INTERVAL = 10 * time.Second
+	JWKS_FETCH_TIMEOUT        = 10 * time.Second
+	MAX_JWKS_SIZE             = 1 << 20
+)
+
+var (
+	errJWTKeyNotFound        = errors.New("key of token is not found")
+	errJWTSecretNotSet       = errors.New("secret of hmac is not set")
+	errJWKSNotSet            = errors.New("jwks file or url is not set")
+	errBearerTokenNotFound   = errors.New("bearer token is not found")
+	errJWKUnsupportedCurve   = errors.New("curve of jwk is not supported")
+	errJWKUnsupportedKeyType = errors.New("key type of jwk is not supported")
+)
+
+/*
+* Claims: claims of token that is verified by JWT middleware
+* Other claims of token are decoded by Decode
+ */
+type Claims struct {
+	jwt.RegisteredClaims
+	Scope scopeList `json:"scope,omitempty"`
+	Scp   scopeList `json:"scp,omitempty"`
+	raw   []byte
+}
+
+/*
+* scopeList: scopes of token, they are a string separated by spaces or an array of strings
+ */
+type scopeList []string
+
+func (scopes *scopeList) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*scopes = strings.Fields(value)
+		return nil
+	}
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*scopes = values
+	return nil
+}
+
+/*
+* Scopes: scopes of token from scope and scp claims
+* @return: []string
+ */
+func (claims *Claims) Scopes() []string {
+	return append(append([]string{}, claims.Scope...), claims.Scp...)
+}
+
+/*
+* HasScope: check token has scope
+* @params: scope string
+* @return: bool
+ */
+func (claims *Claims) HasScope(scope string) bool {
+	for _, value := range claims.Scopes() {
+		if value == scope {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+* Decode: decode payload of token to custom claims
+* @params: claims any (pointer of struct)
+* @return: error
+ */
+func (claims *Claims) Decode(v any) error {
+	return json.Unmarshal(claims.raw, v)
+}
+
+/*
+* Claims: claims of token of request, it is nil if request is not authenticated by JWT middleware
+* @return: *Claims
+ */
+func (ctx *Context) Claims() *Claims {
+	return ctx.claims
+}
+
+/*
+* JWT: middleware verify bearer token in Authorization header, claims are saved to context
+* Signature is verified by secret (HS256) or keys of jwks (RS256, ES256)
+* Token is rejected with 401 if it is invalid, expired or issuer, audience are not matched
+* @params: config JWTConfig
+* @return: Middleware
+ */
+func JWT(config JWTConfig) Middleware {
+	verifier := newJWTVerifier(config)
+	return func(ctx *Context) HttpError {
+		// Browser doesn't send credentials in preflight request
+		if ctx.Method == http.MethodOptions && ctx.GetRequestHeader("Access-Control-Request-Method") != BLANK {
+			ctx.Next()
+			return nil
+		}
+
+		token, err := bearerToken(ctx.GetRequestHeader(AUTHORIZATION_HEADER))
+		if err == nil {
+			ctx.claims, err = verifier.verify(ctx, token)
+		}
+		if err != nil {
+			ctx.LogInfo("Authenticate request fail: %s", err.Error())
+			ctx.rw.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			return HTTP_ERROR_UNAUTHORIZED
+		}
+		ctx.Next()
+		return nil
+	}
+}
+
+/*
+* RequireScopes: middleware reject request with 403 if token doesn't have all scopes
+* It is used after JWT middleware, for example: as middleware of api
+* @params: scopes ...string
+* @return: Middleware
+ */
+func RequireScopes(scopes ...string) Middleware {
+	return func(ctx *Context) HttpError {
+		if ctx.claims == nil {
+			ctx.rw.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			return HTTP_ERROR_UNAUTHORIZED
+		}
+		for _, scope := range scopes {
+			if !ctx.claims.HasScope(scope) {
+				ctx.rw.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, strings.Join(scopes, " ")))
+				return HTTP_ERROR_INSUFFICIENT_SCOPE
+			}
+		}
+		ctx.Next()
+		return nil
+	}
+}
+
+func bearerToken(header string) (string, error) {
+	if len(header) <= len(BEARER_PREFIX) || !strings.EqualFold(header[:len(BEARER_PREFIX)], BEARER_PREFIX) {
+		return BLANK, errBearerTokenNotFound
+	}
+	return strings.TrimSpace(header[len(BEARER_PREFIX):]), nil
+}
+
+/*
+* jwtVerifier: parse and verify token by config
+ */
+type jwtVerifier struct {
+	parser *jwt.Parser
+	secret []byte
+	keys   *jwks
+}
+
+func newJWTVerifier(config JWTConfig) *jwtVerifier {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(config.GetAlgorithms()),
+		jwt.WithLeeway(config.GetClockSkew()),
+		jwt.WithExpirationRequired(),
+	}
+	if config.Issuer != BLANK {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != BLANK {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+
+	verifier := &jwtVerifier{
+		parser: jwt.NewParser(options...),
+		secret: []byte(config.Secret),
+	}
+	if config.JWKSFile != BLANK || config.JWKSURL != BLANK {
+		verifier.keys = newJWKS(config.JWKSFile, config.JWKSURL, config.GetJWKSRefreshInterval())
+		verifier.keys.refresh(context.Background())
+	}
+	return verifier
+}
+
+func (verifier *jwtVerifier) verify(ctx context.Context, tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := verifier.parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		return verifier.key(ctx, token)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep payload, so custom claims can be decoded
+	claims.raw, err = verifier.parser.DecodeSegment(strings.Split(token.Raw, ".")[1])
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+/*
+* key: get key to verify signature of token, type of key must be matched with algorithm
+ */
+func (verifier *jwtVerifier) key(ctx context.Context, token *jwt.Token) (any, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(verifier.secret) == 0 {
+			return nil, errJWTSecretNotSet
+		}
+		return verifier.secret, nil
+	}
+	if verifier.keys == nil {
+		return nil, errJWKSNotSet
+	}
+	kid, _ := token.Header["kid"].(string)
+	return verifier.keys.getKey(ctx, kid, token.Method.Alg())
+}
+
+/*
+* jwks: public keys from jwks file or url, they are refreshed after refresh interval
+* Unknown key id refreshes keys, so rotated keys are used without restart
+ */
+type jwks struct {
+	mutex           sync.RWMutex
+	file            string
+	url             string
+	refreshInterval time.Duration
+	keys            map[string]jwk
+	updatedAt       time.Time
+	refreshedAt     time.Time
+	refreshing      chan struct{}
+}
+
+type jwk struct {
+	alg string
+	key any
+}
+
+type jwkJSON struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func newJWKS(file string, url string, refreshInterval time.Duration) *jwks {
+	return &jwks{
+		file:            file,
+		url:             url,
+		refreshInterval: refreshInterval,
+		keys:            make(map[string]jwk),
+	}
+}
+
+/*
+* getKey: get key by key id, key is the only key with algorithm if token has no key id
+* Expired keys are refreshed in background, unknown key id waits for a refresh that is shared by all requests
+ */
+func (set *jwks) getKey(ctx context.Context, kid string, alg string) (any, error) {
+	set.mutex.RLock()
+	expired := time.Since(set.updatedAt) > set.refreshInterval
+	key, ok := set.findKey(kid, alg)
+	set.mutex.RUnlock()
+
+	if ok {
+		if expired {
+			if done, leader := set.beginRefresh(false); leader {
+				go set.runRefresh(context.Background(), done)
+			}
+		}
+		return key, nil
+	}
+
+	done, leader := set.beginRefresh(false)
+	if leader {
+		// Keys are shared by waiting requests, so loading is not canceled with this request
+		set.runRefresh(context.WithoutCancel(ctx), done)
+	} else if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	set.mutex.RLock()
+	key, ok = set.findKey(kid, alg)
+	set.mutex.RUnlock()
+	if !ok {
+		return nil, errJWTKeyNotFound
+	}
+	return key, nil
+}
+
+/*
+* beginRefresh: start a refresh if no refresh is running and keys are not refreshed in JWKS_MIN_REFRESH_INTERVAL
+* refreshedAt is set before loading, so requests during loading don't start another refresh
+* @return: done is closed when running refresh is finished, it is nil if refresh is throttled; leader is true if caller must load keys
+ */
+func (set *jwks) beginRefresh(force bool) (chan struct{}, bool) {
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+	if set.refreshing != nil {
+		return set.refreshing, false
+	}
+	if !force && time.Since(set.refreshedAt) <= JWKS_MIN_REFRESH_INTERVAL {
+		return nil, false
+	}
+	set.refreshing = make(chan struct{})
+	set.refreshedAt = time.Now()
+	return set.refreshing, true
+}
+
+/*
+* runRefresh: load keys and wake up requests that wait for refresh
+ */
+func (set *jwks) runRefresh(ctx context.Context, done chan struct{}) error {
+	err := set.load(ctx)
+	if err != nil {
+		LoggerInstance.Error("Refresh jwks fail: %s", err.Error())
+	}
+	set.mutex.Lock()
+	set.refreshing = nil
+	set.mutex.Unlock()
+	close(done)
+	return err
+}
+
+func (set *jwks) findKey(kid string, alg string) (any, bool) {
+	if kid != BLANK {
+		key, ok := set.keys[kid]
+		if !ok || (key.alg != BLANK && key.alg != alg) {
+			return nil, false
+		}
+		return key.key, true
+	}
+
+	var found any
+	for _, key := range set.keys {
+		if key.alg == alg {
+			if found != nil {
+				return nil, false
+			}
+			found = key.key
+		}
+	}
+	return found, found != nil
+}
+
+/*
+* refresh: load keys now, it waits for running refresh instead of loading keys again
+ */
+func (set *jwks) refresh(ctx context.Context) error {
+	done, leader := set.beginRefresh(true)
+	if !leader {
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return set.runRefresh(ctx, done)
+}
+
+/*
+* load: load keys from file or url, keys are kept if loading fails
+ */
+func (set *jwks) load(ctx context.Context) error {
+	data, err := set.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	var document struct {
+		Keys []jwkJSON `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+
+	keys := make(map[string]jwk, len(document.Keys))
+	for i, item := range document.Keys {
+		if item.Use != BLANK && item.Use != "sig" {
+			continue
+		}
+		key, err := item.parse()
+		if err != nil {
+			LoggerInstance.Warning("Jwk %s is ignored: %s", item.Kid, err.Error())
+			continue
+		}
+		kid := item.Kid
+		if kid == BLANK {
+			kid = fmt.Sprintf("#%d", i)
+		}
+		keys[kid] = key
+	}
+
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+	set.keys = keys
+	set.updatedAt = time.Now()
+	return nil
+}
+
+func (set *jwks) fetch(ctx context.Context) ([]byte, error) {
+	if set.file != BLANK {
+		return os.ReadFile(set.file)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, JWKS_FETCH_TIMEOUT)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, set.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get jwks fail: status %d", response.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(response.Body, MAX_JWKS_SIZE))
+}
+
+/*
+* parse: convert jwk to public key, algorithm is inferred from key if it is not set
+ */
+func (item jwkJSON) parse() (jwk, error) {
+	switch item.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(item.N)
+		if err != nil {
+			return jwk{}, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(item.E)
+		if err != nil {
+			return jwk{}, err
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		return jwk{alg: defaultString(item.Alg, JWT_ALGORITHM_RS256), key: key}, nil
+	case "EC":
+		var curve elliptic.Curve
+		var alg string
+		switch item.Crv {
+		case "P-256":
+			curve, alg = elliptic.P256(), JWT_ALGORITHM_ES256
+		case "P-384":
+			curve, alg = elliptic.P384(), "ES384"
+		case "P-521":
+			curve, alg = elliptic.P521(), "ES512"
+		default:
+			return jwk{}, errJWKUnsupportedCurve
+		}
+		x, err := base64.RawURLEncoding.DecodeString(item.X)
+		if err != nil {
+			return jwk{}, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(item.Y)
+		if err != nil {
+			return jwk{}, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		return jwk{alg: defaultString(item.Alg, alg), key: key}, nil
+	default:
+		return jwk{}, errJWKUnsupportedKeyType
+	}
+}
+
+func defaultString(value string, defaultValue string) string {
+	if value == BLANK {
+		return defaultValue
+	}
+	return value
+}
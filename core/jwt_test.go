@@ -0,0 +1,249 @@
+package core
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const jwtTestSecret = "secret-of-test"
+
+func signTestToken(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != BLANK {
+		token.Header["kid"] = kid
+	}
+	value, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("Sign token fail: %v", err)
+	}
+	return value
+}
+
+func newTestClaims(expiresIn time.Duration) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "user-1",
+		"iss":   "https://auth.example.com",
+		"aud":   "items",
+		"exp":   time.Now().Add(expiresIn).Unix(),
+		"scope": "items:read items:write",
+		"name":  "Alice",
+	}
+}
+
+func runJWT(middlewares []Middleware, token string) (*Context, *httptest.ResponseRecorder) {
+	ctx, recorder := newRateLimitTestContext("10.0.0.1:5000", "/items")
+	if token != BLANK {
+		ctx.request.Header.Set(AUTHORIZATION_HEADER, BEARER_PREFIX+token)
+	}
+	ctx.runMiddlewares(middlewares)
+	return ctx, recorder
+}
+
+func TestJWT_HS256(t *testing.T) {
+	middleware := JWT(JWTConfig{Secret: jwtTestSecret, Issuer: "https://auth.example.com", Audience: "items"})
+
+	ctx, recorder := runJWT([]Middleware{middleware}, signTestToken(t, jwt.SigningMethodHS256, []byte(jwtTestSecret), BLANK, newTestClaims(time.Minute)))
+	if recorder.Code != http.StatusOK || ctx.Claims() == nil {
+		t.Fatalf("Expected authenticated request, got %d", recorder.Code)
+	}
+	if subject, _ := ctx.Claims().GetSubject(); subject != "user-1" || !ctx.Claims().HasScope("items:write") {
+		t.Errorf("Unexpected claims: %+v", ctx.Claims())
+	}
+	custom := struct {
+		Name string `json:"name"`
+	}{}
+	if err := ctx.Claims().Decode(&custom); err != nil || custom.Name != "Alice" {
+		t.Errorf("Expected custom claim, got %v %v", custom, err)
+	}
+}
+
+func TestJWT_Rejected(t *testing.T) {
+	clockSkew := 10
+	middleware := JWT(JWTConfig{Secret: jwtTestSecret, Issuer: "https://auth.example.com", Audience: "items", ClockSkew: &clockSkew})
+	wrongAudience := newTestClaims(time.Minute)
+	wrongAudience["aud"] = "payments"
+	noExpiry := newTestClaims(time.Minute)
+	delete(noExpiry, "exp")
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	testCases := map[string]string{
+		"no token":        BLANK,
+		"wrong secret":    signTestToken(t, jwt.SigningMethodHS256, []byte("other"), BLANK, newTestClaims(time.Minute)),
+		"expired":         signTestToken(t, jwt.SigningMethodHS256, []byte(jwtTestSecret), BLANK, newTestClaims(-time.Minute)),
+		"wrong audience":  signTestToken(t, jwt.SigningMethodHS256, []byte(jwtTestSecret), BLANK, wrongAudience),
+		"no expiry":       signTestToken(t, jwt.SigningMethodHS256, []byte(jwtTestSecret), BLANK, noExpiry),
+		"other algorithm": signTestToken(t, jwt.SigningMethodRS256, rsaKey, BLANK, newTestClaims(time.Minute)),
+	}
+	for name, token := range testCases {
+		ctx, recorder := runJWT([]Middleware{middleware}, token)
+		if recorder.Code != http.StatusUnauthorized || ctx.Claims() != nil {
+			t.Errorf("%s: expected 401, got %d", name, recorder.Code)
+		}
+		if recorder.Header().Get("WWW-Authenticate") == BLANK {
+			t.Errorf("%s: expected WWW-Authenticate header", name)
+		}
+	}
+
+	// Token is expired 5 seconds ago, it is in clock skew
+	_, recorder := runJWT([]Middleware{middleware}, signTestToken(t, jwt.SigningMethodHS256, []byte(jwtTestSecret), BLANK, newTestClaims(-5*time.Second)))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected token in clock skew is accepted, got %d", recorder.Code)
+	}
+}
+
+func encodeTestJWK(kid string, key any) map[string]string {
+	encode := func(value *big.Int) string { return base64.RawURLEncoding.EncodeToString(value.Bytes()) }
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kid": kid, "kty": "RSA", "n": encode(key.N), "e": encode(big.NewInt(int64(key.E)))}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kid": kid, "kty": "EC", "crv": "P-256", "x": encode(key.X), "y": encode(key.Y)}
+	}
+	return nil
+}
+
+func TestJWT_JWKSRefreshIsThrottled(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var rotated atomic.Bool
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		fetches.Add(1)
+		keys := []map[string]string{encodeTestJWK("old", &oldKey.PublicKey)}
+		if rotated.Load() {
+			keys = []map[string]string{encodeTestJWK("new", &newKey.PublicKey)}
+		}
+		json.NewEncoder(writer).Encode(map[string]any{"keys": keys})
+	}))
+	defer server.Close()
+
+	middleware := JWT(JWTConfig{JWKSURL: server.URL})
+	if _, recorder := runJWT([]Middleware{middleware}, signTestToken(t, jwt.SigningMethodRS256, oldKey, "old", newTestClaims(time.Minute))); recorder.Code != http.StatusOK {
+		t.Fatalf("Expected token of old key is accepted, got %d", recorder.Code)
+	}
+
+	rotated.Store(true)
+	if _, recorder := runJWT([]Middleware{middleware}, signTestToken(t, jwt.SigningMethodRS256, newKey, "new", newTestClaims(time.Minute))); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected key set is not refreshed right after loading, got %d", recorder.Code)
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("Expected key set is fetched once, got %d", fetches.Load())
+	}
+}
+
+func TestJWT_UnknownKeyRefreshesKeySet(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var served atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		keys := []map[string]string{}
+		if served.Load() {
+			keys = append(keys, encodeTestJWK("rotated", &key.PublicKey))
+		}
+		json.NewEncoder(writer).Encode(map[string]any{"keys": keys})
+	}))
+	defer server.Close()
+
+	set := newJWKS(BLANK, server.URL, time.Hour)
+	set.refresh(context.Background())
+	served.Store(true)
+	set.refreshedAt = time.Now().Add(-JWKS_MIN_REFRESH_INTERVAL)
+	if _, err := set.getKey(context.Background(), "rotated", JWT_ALGORITHM_RS256); err != nil {
+		t.Errorf("Expected rotated key is loaded, got %v", err)
+	}
+}
+
+func TestJWT_UnknownKeyRefreshIsShared(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		keys := []map[string]string{}
+		if fetches.Load() > 1 {
+			keys = append(keys, encodeTestJWK("rotated", &key.PublicKey))
+		}
+		json.NewEncoder(writer).Encode(map[string]any{"keys": keys})
+	}))
+	defer server.Close()
+
+	set := newJWKS(BLANK, server.URL, time.Hour)
+	set.refresh(context.Background())
+	set.refreshedAt = time.Now().Add(-JWKS_MIN_REFRESH_INTERVAL)
+
+	var wg sync.WaitGroup
+	var found atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := set.getKey(context.Background(), "rotated", JWT_ALGORITHM_RS256); err == nil {
+				found.Add(1)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if fetches.Load() != 2 {
+		t.Errorf("Expected unknown key refreshes key set once, got %d fetches", fetches.Load()-1)
+	}
+	if found.Load() != 10 {
+		t.Errorf("Expected all requests get rotated key, got %d", found.Load())
+	}
+}
+
+func TestJWT_ZeroClockSkew(t *testing.T) {
+	clockSkew := 0
+	if skew := (JWTConfig{ClockSkew: &clockSkew}).GetClockSkew(); skew != 0 {
+		t.Errorf("Expected clock skew 0, got %v", skew)
+	}
+	if skew := (JWTConfig{}).GetClockSkew(); skew != DEFAULT_JWT_CLOCK_SKEW*time.Second {
+		t.Errorf("Expected default clock skew, got %v", skew)
+	}
+
+	middleware := JWT(JWTConfig{Secret: jwtTestSecret, ClockSkew: &clockSkew})
+	_, recorder := runJWT([]Middleware{middleware}, signTestToken(t, jwt.SigningMethodHS256, []byte(jwtTestSecret), BLANK, newTestClaims(-5*time.Second)))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("Expected expired token is rejected without clock skew, got %d", recorder.Code)
+	}
+}
+
+func TestJWT_ES256FromFileAndScopes(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	data, _ := json.Marshal(map[string]any{"keys": []map[string]string{encodeTestJWK("ec", &key.PublicKey)}})
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	os.WriteFile(file, data, 0600)
+
+	middlewares := []Middleware{JWT(JWTConfig{JWKSFile: file}), RequireScopes("items:write", "items:delete")}
+	ctx, recorder := runJWT(middlewares, signTestToken(t, jwt.SigningMethodES256, key, "ec", newTestClaims(time.Minute)))
+	if ctx.Claims() == nil {
+		t.Fatalf("Expected token of ec key is accepted, got %d", recorder.Code)
+	}
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without items:delete scope, got %d", recorder.Code)
+	}
+
+	ctx, recorder = runJWT([]Middleware{JWT(JWTConfig{JWKSFile: file}), RequireScopes("items:read")}, signTestToken(t, jwt.SigningMethodES256, key, "ec", newTestClaims(time.Minute)))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected 200 with items:read scope, got %d", recorder.Code)
+	}
+}
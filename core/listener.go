@@ -0,0 +1,202 @@
+package core
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+const CERT_RELOAD_CHECK_INTERVAL = 5 * time.Second
+
+var errClientCAInvalid = errors.New("client ca file has no valid certificate")
+
+/*
+* newServer: create http server of a listener
+* @params: config ListenerConfig
+* @return: *http.Server, net.Listener, error
+ */
+func newServer(config ListenerConfig) (*http.Server, net.Listener, error) {
+	server := &http.Server{
+		Addr:              config.Address,
+		Handler:           http.DefaultServeMux,
+		ReadTimeout:       time.Duration(config.ReadTimeout) * time.Second,
+		ReadHeaderTimeout: time.Duration(config.ReadHeaderTimeout) * time.Second,
+		WriteTimeout:      time.Duration(config.WriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(config.IdleTimeout) * time.Second,
+	}
+
+	if config.IsTLS() {
+		tlsConfig, err := newTLSConfig(config)
+		if err != nil {
+			return nil, nil, err
+		}
+		server.TLSConfig = tlsConfig
+		if !config.HTTP2 {
+			// Empty map disables HTTP/2 that is enabled by default for tls
+			server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		}
+	} else if config.H2C {
+		server.Handler = h2c.NewHandler(server.Handler, &http2.Server{})
+	}
+
+	listener, err := newListener(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return server, listener, nil
+}
+
+/*
+* newListener: listen to unix socket or tcp address of a listener
+* Old socket file is removed before listening
+ */
+func newListener(config ListenerConfig) (net.Listener, error) {
+	if config.Socket == BLANK {
+		return net.Listen("tcp", config.Address)
+	}
+
+	if err := os.Remove(config.Socket); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return net.Listen("unix", config.Socket)
+}
+
+/*
+* newTLSConfig: create tls config that reloads certificate when files change
+* Client certificate is required and verified when client ca file is set (mTLS)
+ */
+func newTLSConfig(config ListenerConfig) (*tls.Config, error) {
+	reloader, err := newCertReloader(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.getCertificate,
+	}
+
+	if config.ClientCAFile != BLANK {
+		data, err := os.ReadFile(config.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errClientCAInvalid
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+/*
+* certReloader: keep certificate of a listener, it is reloaded when cert or key file is modified
+ */
+type certReloader struct {
+	certFile  string
+	keyFile   string
+	mutex     sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkTime time.Time
+}
+
+/*
+* newCertReloader: load certificate from files
+* @params: certFile string
+* @params: keyFile string
+* @return: *certReloader, error
+ */
+func newCertReloader(certFile string, keyFile string) (*certReloader, error) {
+	reloader := &certReloader{certFile: certFile, keyFile: keyFile}
+	modTime, err := reloader.lastModTime()
+	if err != nil {
+		return nil, err
+	}
+	if err := reloader.load(modTime); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+/*
+* getCertificate: callback of tls config, files are checked at most once per CERT_RELOAD_CHECK_INTERVAL
+* Old certificate is kept when new files can't be loaded
+ */
+func (reloader *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+
+	if time.Since(reloader.checkTime) < CERT_RELOAD_CHECK_INTERVAL {
+		return reloader.cert, nil
+	}
+	reloader.checkTime = time.Now()
+
+	modTime, err := reloader.lastModTime()
+	if err != nil {
+		LoggerInstance.Error("Check certificate %s fail: %s", reloader.certFile, err.Error())
+		return reloader.cert, nil
+	}
+	if modTime.After(reloader.modTime) {
+		if err := reloader.load(modTime); err != nil {
+			LoggerInstance.Error("Reload certificate %s fail: %s", reloader.certFile, err.Error())
+		} else {
+			LoggerInstance.Info("Reload certificate: %s", reloader.certFile)
+		}
+	}
+	return reloader.cert, nil
+}
+
+/*
+* load: load certificate from files, modTime is time of files that is loaded
+ */
+func (reloader *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(reloader.certFile, reloader.keyFile)
+	if err != nil {
+		return err
+	}
+	reloader.cert = &cert
+	reloader.modTime = modTime
+	reloader.checkTime = time.Now()
+	return nil
+}
+
+/*
+* lastModTime: latest modification time of cert and key files
+ */
+func (reloader *certReloader) lastModTime() (time.Time, error) {
+	certInfo, err := os.Stat(reloader.certFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	keyInfo, err := os.Stat(reloader.keyFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if keyInfo.ModTime().After(certInfo.ModTime()) {
+		return keyInfo.ModTime(), nil
+	}
+	return certInfo.ModTime(), nil
+}
+
+/*
+* listenerName: name of listener in logs
+ */
+func listenerName(config ListenerConfig) string {
+	if config.Socket != BLANK {
+		return fmt.Sprintf("unix:%s", config.Socket)
+	}
+	return config.Address
+}
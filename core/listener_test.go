@@ -0,0 +1,95 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestCertificate(t *testing.T, certFile string, keyFile string, name string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Generate key fail: %s", err.Error())
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Create certificate fail: %s", err.Error())
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Marshal key fail: %s", err.Error())
+	}
+
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+}
+
+func TestCertReloader_ReloadChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	writeTestCertificate(t, certFile, keyFile, "old")
+
+	reloader, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	oldCert, _ := reloader.getCertificate(nil)
+
+	writeTestCertificate(t, certFile, keyFile, "new")
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, future, future)
+	reloader.checkTime = time.Time{}
+
+	newCert, _ := reloader.getCertificate(nil)
+	if bytes.Equal(oldCert.Certificate[0], newCert.Certificate[0]) {
+		t.Errorf("Expected certificate is reloaded")
+	}
+}
+
+func TestNewServer_UnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "server.sock")
+	server, listener, err := newServer(ListenerConfig{Socket: socket})
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	server.Handler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("ok"))
+	})
+	go server.Serve(listener)
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return net.Dial("unix", socket)
+		},
+	}}
+	response, err := client.Get("http://unix/")
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(response.Body)
+	if string(body) != "ok" {
+		t.Errorf("Expected ok, got %s", string(body))
+	}
+}
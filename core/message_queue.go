@@ -201,18 +201,40 @@ func (mqs *MessageQueueSession) CloseSession() {
 	}
 }
 
+/*
+* Publish: publish message with core context, request id and span of caller are not sent to consumer
+*
+* Deprecated: use PublishWithContext with context of request, it is required to propagate request id to consumer
+* @params: body []byte
+* @return: Error
+ */
 func (mqs *MessageQueueSession) Publish(body []byte) Error {
+	return mqs.PublishWithContext(coreContext, body)
+}
+
+/*
+* PublishWithContext: publish message with request id of context in headers
+* Consumer of message gets the request id, so logs can be joined across services
+* @params: ctx *Context
+* @params: body []byte
+* @return: Error
+ */
+func (mqs *MessageQueueSession) PublishWithContext(ctx *Context, body []byte) Error {
+	headers, span := startPublishSpan(ctx, mqs.config.QueueName, ctx.messageHeaders())
 	err := mqs.channel.PublishWithContext(
-		coreContext,
+		ctx,
 		mqs.config.ExchangeName,
 		mqs.config.RouteKey,
 		false,
 		false,
 		amqp.Publishing{
 			ContentType: CONTENT_TYPE_TEXT,
+			Headers:     headers,
 			Body:        body,
 		},
 	)
+	endSpan(span, err)
+	observePublish(mqs.config.QueueName, err)
 
 	if err != nil {
 		LoggerInstance.Error("Publish error: %v", err)
@@ -224,6 +246,27 @@ func (mqs *MessageQueueSession) Publish(body []byte) Error {
 
 type RabbitmqMessage struct {
 	Body []byte
+	// Request id of context that publishes message, blank if it is not published with context
+	RequestID string
+	// Context of message, it is returned to pool after handler is done
+	Context *Context
+}
+
+/*
+* newConsumeContext: context to handle consumed message, it carries request id and span of publisher
+* Span is ended when context is returned to pool by PutContext
+ */
+func newConsumeContext(queue string, headers amqp.Table) *Context {
+	ctx := GetContextWithTimeout(Config.GetTaskTimeout())
+	if requestID := requestIDFromMessage(headers); requestID != BLANK {
+		ctx.requestID = requestID
+	}
+	spanContext, span := startConsumeSpan(ctx.Context, queue, headers)
+	ctx.Context = spanContext
+	ctx.OnFinish(func(ctx *Context) {
+		span.End()
+	})
+	return ctx
 }
 
 type ConsumerHandler func(msg RabbitmqMessage)
@@ -239,12 +282,23 @@ func (mqs *MessageQueueSession) Consume(handler ConsumerHandler) Error {
 		return ERROR_CANNOT_CONSUME_MESSAGES_FROM_RABBITMQ
 	}
 
+	registerConsumer(mqs.config.QueueName, func() error {
+		return mqs.channel.Cancel(consumerTag, false)
+	})
+
 	// Handle message from rabbitmq
 	go func(c <-chan amqp.Delivery) {
+		defer consumerWait.Done()
 		for message := range c {
+			start := time.Now()
+			ctx := newConsumeContext(mqs.config.QueueName, message.Headers)
 			handler(RabbitmqMessage{
-				Body: message.Body,
+				Body:      message.Body,
+				RequestID: requestIDFromMessage(message.Headers),
+				Context:   ctx,
 			})
+			PutContext(ctx)
+			observeConsume(mqs.config.QueueName, start)
 		}
 	}(messages)
 
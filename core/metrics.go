@@ -0,0 +1,179 @@
+package core
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	METRICS_DEFAULT_PATH = "/metrics"
+	METRICS_METHOD_OTHER = "OTHER"
+)
+
+/*
+* metricsRegistry: registry of metrics that are served at metrics path
+* Metrics are recorded even if endpoint is disabled, it is cheap
+ */
+var metricsRegistry = prometheus.NewRegistry()
+var registerDBStatsOnce sync.Once
+
+var (
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_server_requests_total",
+		Help: "Number of http requests by route and status.",
+	}, []string{"method", "route", "status"})
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_server_request_duration_seconds",
+		Help:    "Latency of http requests by route and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpClientRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_client_requests_total",
+		Help: "Number of outgoing http requests by host and status.",
+	}, []string{"host", "method", "status"})
+	httpClientRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_client_request_duration_seconds",
+		Help:    "Latency of outgoing http requests by host.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"host", "method"})
+
+	amqpPublishedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "amqp_published_total",
+		Help: "Number of messages published to rabbitmq by queue and result.",
+	}, []string{"queue", "result"})
+	amqpConsumedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "amqp_consumed_total",
+		Help: "Number of messages consumed from rabbitmq by queue.",
+	}, []string{"queue"})
+	amqpConsumeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "amqp_consume_duration_seconds",
+		Help:    "Time to handle a consumed message by queue.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"queue"})
+
+	schedulerTodosDue = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "scheduler_todos_due",
+		Help: "Number of todos that are due at last execution of worker.",
+	})
+	schedulerTasksProcessed = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "scheduler_tasks_processed_total",
+		Help: "Number of tasks that are processed by worker.",
+	})
+	schedulerLag = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "scheduler_lag_seconds",
+		Help: "Time between oldest due bucket and current bucket at last execution of worker.",
+	})
+	schedulerLockContention = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduler_lock_contention_total",
+		Help: "Number of tasks that are skipped because lock is held by other worker or can't be acquired.",
+	}, []string{"reason"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestsTotal,
+		httpRequestDuration,
+		httpClientRequestsTotal,
+		httpClientRequestDuration,
+		amqpPublishedTotal,
+		amqpConsumedTotal,
+		amqpConsumeDuration,
+		schedulerTodosDue,
+		schedulerTasksProcessed,
+		schedulerLag,
+		schedulerLockContention,
+	)
+}
+
+/*
+* RegisterCollector: register collector of service, its metrics are served with core metrics
+* @params: collector prometheus.Collector
+* @return: error (collector is invalid or registered before)
+ */
+func RegisterCollector(collector prometheus.Collector) error {
+	return metricsRegistry.Register(collector)
+}
+
+/*
+* registerMetricsRoute: serve metrics in prometheus format at metrics path in config
+* Pool stats of database are collected when metrics are scraped
+ */
+func registerMetricsRoute() {
+	if sqliteSession.DB != nil {
+		registerDBStatsOnce.Do(func() {
+			metricsRegistry.MustRegister(collectors.NewDBStatsCollector(sqliteSession.DB, "sqlite"))
+		})
+	}
+
+	handler := promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{})
+	registerRoute(Config.Metrics.GetPath(), http.MethodGet, func(writer http.ResponseWriter, request *http.Request, params pathParams) {
+		handler.ServeHTTP(writer, request)
+	}, nil)
+}
+
+/*
+* observeRequest: record count and latency of request when it is done
+* @params: route string (url pattern of api)
+ */
+func (ctx *Context) observeRequest(route string) {
+	start := time.Now()
+	ctx.OnFinish(func(ctx *Context) {
+		method := metricsMethod(ctx.Method)
+		status := strconv.Itoa(ctx.GetResponseStatus())
+		httpRequestsTotal.WithLabelValues(method, route, status).Inc()
+		httpRequestDuration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+/*
+* metricsMethod: label of request method, non-standard method is METRICS_METHOD_OTHER
+* Method of request is sent by client, so it is bounded to keep cardinality of metrics
+ */
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return METRICS_METHOD_OTHER
+}
+
+/*
+* observeClientRequest: record count and latency of outgoing request
+ */
+func observeClientRequest(request *http.Request, response *http.Response, err error, start time.Time) {
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(response.StatusCode)
+	}
+	httpClientRequestsTotal.WithLabelValues(request.URL.Host, request.Method, status).Inc()
+	httpClientRequestDuration.WithLabelValues(request.URL.Host, request.Method).Observe(time.Since(start).Seconds())
+}
+
+/*
+* observePublish: record result of publishing message
+ */
+func observePublish(queue string, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	amqpPublishedTotal.WithLabelValues(queue, result).Inc()
+}
+
+/*
+* observeConsume: record a consumed message and time to handle it
+ */
+func observeConsume(queue string, start time.Time) {
+	amqpConsumedTotal.WithLabelValues(queue).Inc()
+	amqpConsumeDuration.WithLabelValues(queue).Observe(time.Since(start).Seconds())
+}
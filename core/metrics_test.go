@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics_ObserveRequest(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/metrics-test/1", nil)
+	ctx := &Context{Context: context.Background(), Method: http.MethodPost, request: request}
+	ctx.response = responseWriter{ResponseWriter: httptest.NewRecorder()}
+	ctx.rw = &ctx.response
+
+	before := testutil.ToFloat64(httpRequestsTotal.WithLabelValues(http.MethodPost, "/metrics-test/{id}", "201"))
+	ctx.observeRequest("/metrics-test/{id}")
+	ctx.endResponse(http.StatusCreated, "{}")
+	ctx.runFinishHooks()
+
+	after := testutil.ToFloat64(httpRequestsTotal.WithLabelValues(http.MethodPost, "/metrics-test/{id}", "201"))
+	if after != before+1 {
+		t.Errorf("Expected counter is increased by 1, got %v -> %v", before, after)
+	}
+}
+
+func TestMetrics_ObserveRequestNonStandardMethod(t *testing.T) {
+	useTestRouter(t)
+	RegisterAPI("/metrics-method", http.MethodGet, corsTestHandler)
+
+	before := testutil.ToFloat64(httpRequestsTotal.WithLabelValues(METRICS_METHOD_OTHER, "/metrics-method", "405"))
+	serveTestRequest("FOO", "/metrics-method", nil)
+	serveTestRequest("BAR", "/metrics-method", nil)
+
+	if value := testutil.ToFloat64(httpRequestsTotal.WithLabelValues(METRICS_METHOD_OTHER, "/metrics-method", "405")); value != before+2 {
+		t.Errorf("Expected non-standard methods are recorded as %s, got %v", METRICS_METHOD_OTHER, value-before)
+	}
+	for _, method := range []string{"FOO", "BAR"} {
+		if httpRequestsTotal.DeleteLabelValues(method, "/metrics-method", "405") {
+			t.Errorf("Expected no series for method %s", method)
+		}
+	}
+}
+
+func TestMetrics_ObservePublish(t *testing.T) {
+	before := testutil.ToFloat64(amqpPublishedTotal.WithLabelValues("metrics_test_queue", "error"))
+	observePublish("metrics_test_queue", errors.New("closed"))
+	observePublish("metrics_test_queue", nil)
+
+	if value := testutil.ToFloat64(amqpPublishedTotal.WithLabelValues("metrics_test_queue", "error")); value != before+1 {
+		t.Errorf("Expected 1 error is recorded, got %v", value-before)
+	}
+	if value := testutil.ToFloat64(amqpPublishedTotal.WithLabelValues("metrics_test_queue", "success")); value < 1 {
+		t.Errorf("Expected success is recorded, got %v", value)
+	}
+}
+
+func TestMetrics_Scrape(t *testing.T) {
+	observeConsume("metrics_scrape_queue", time.Now())
+	schedulerLockContention.WithLabelValues("held").Inc()
+
+	recorder := httptest.NewRecorder()
+	promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, METRICS_DEFAULT_PATH, nil))
+	data, _ := io.ReadAll(recorder.Body)
+	body := string(data)
+
+	for _, name := range []string{
+		`amqp_consumed_total{queue="metrics_scrape_queue"} 1`,
+		`scheduler_lock_contention_total{reason="held"}`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, name) {
+			t.Errorf("Expected %s in metrics", name)
+		}
+	}
+}
@@ -1,23 +1,20 @@
 package core
 
-import "net/http"
-
-func corsMiddleware(ctx *Context) HttpError {
-	ctx.rw.Header().Set("Access-Control-Allow-Origin", "*")
-	ctx.rw.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-	ctx.rw.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-
-	if ctx.Method == http.MethodOptions {
-		ctx.rw.WriteHeader(http.StatusNoContent)
-		return nil
+/*
+* runMiddlewares: call middlewares in order
+* @return bool: true if a middleware ends the request (it doesn't call ctx.Next)
+ */
+func (ctx *Context) runMiddlewares(middlewares []Middleware) bool {
+	for _, middleware := range middlewares {
+		ctx.isRequestEnd = true
+		if err := middleware(ctx); ctx.isRequestEnd {
+			if err != nil {
+				ctx.writeError(err)
+			}
+			return true
+		}
 	}
-
-	ctx.Next()
-	return nil
-}
-
-func UserCorsMiddleware() {
-	UseMiddleware(corsMiddleware)
+	return false
 }
 
 func UseMiddleware(middleware Middleware) {
@@ -0,0 +1,560 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	OPENAPI_VERSION               = "3.1.0"
+	OPENAPI_DEFAULT_PATH          = "/openapi.json"
+	OPENAPI_SCHEMA_REF_PREFIX     = "#/components/schemas/"
+	OPENAPI_ERROR_RESPONSE_SCHEMA = "ErrorResponse"
+)
+
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                       `json:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas,omitempty"`
+}
+
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+type OpenAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required,omitempty"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+	Enum                 []any                     `json:"enum,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64                  `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64                  `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int                      `json:"minLength,omitempty"`
+	MaxLength            *int                      `json:"maxLength,omitempty"`
+	MinItems             *int                      `json:"minItems,omitempty"`
+	MaxItems             *int                      `json:"maxItems,omitempty"`
+}
+
+/*
+* openAPIBuilder: build api document from registered routes
+ */
+type openAPIBuilder struct {
+	document *OpenAPIDocument
+	names    map[reflect.Type]string
+	// Content type of error body of response envelope
+	errorContentType string
+}
+
+/*
+* BuildOpenAPIDocument: build OpenAPI 3.1 document from all registered apis
+* @return *OpenAPIDocument
+ */
+func BuildOpenAPIDocument() *OpenAPIDocument {
+	errorSchema := envelopeSchema(&OpenAPISchema{})
+	builder := &openAPIBuilder{
+		document: &OpenAPIDocument{
+			OpenAPI: OPENAPI_VERSION,
+			Info: OpenAPIInfo{
+				Title:   Config.OpenAPI.GetTitle(),
+				Version: Config.OpenAPI.GetVersion(),
+			},
+			Paths: make(map[string]map[string]*OpenAPIOperation),
+			Components: OpenAPIComponents{
+				Schemas: map[string]*OpenAPISchema{
+					OPENAPI_ERROR_RESPONSE_SCHEMA: errorSchema.Error,
+				},
+			},
+		},
+		names:            make(map[reflect.Type]string),
+		errorContentType: errorSchema.ErrorContentType,
+	}
+
+	urls := make([]string, 0, len(routeMap))
+	for url := range routeMap {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	for _, url := range urls {
+		for _, route := range routeMap[url] {
+			if route.info.requestType == nil {
+				continue
+			}
+			openAPIPath := toOpenAPIPath(url)
+			if builder.document.Paths[openAPIPath] == nil {
+				builder.document.Paths[openAPIPath] = make(map[string]*OpenAPIOperation)
+			}
+			builder.document.Paths[openAPIPath][strings.ToLower(route.Method)] = builder.buildOperation(route)
+		}
+	}
+
+	return builder.document
+}
+
+/*
+* registerOpenAPIRoute: serve api document at path in config
+ */
+func registerOpenAPIRoute() {
+	var once sync.Once
+	var body []byte
+	var err error
+	handler := func(writer http.ResponseWriter, request *http.Request, params pathParams) {
+		// Document is built once at first request, all apis are registered before
+		once.Do(func() {
+			body, err = json.Marshal(BuildOpenAPIDocument())
+		})
+		if err != nil {
+			LoggerInstance.Error("Marshal openapi document fail: %s", err.Error())
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set(CONTENT_TYPE_KEY, JSON_CONTENT_TYPE)
+		writer.Write(body)
+	}
+
+	registerRoute(Config.OpenAPI.GetPath(), http.MethodGet, handler, nil)
+}
+
+/*
+* toOpenAPIPath: convert url pattern to openapi path: /files/*rest => /files/{rest}
+ */
+func toOpenAPIPath(url string) string {
+	segments := strings.Split(url, "/")
+	for i, segment := range segments {
+		if kind, name := parseSegment(segment); kind == segmentKind_Wildcard {
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func (builder *openAPIBuilder) buildOperation(route Route) *OpenAPIOperation {
+	operation := &OpenAPIOperation{
+		OperationID: strings.ToLower(route.Method) + strings.ReplaceAll(strings.ReplaceAll(strings.ReplaceAll(route.URL, "/", "_"), "{", BLANK), "}", BLANK),
+		Responses:   make(map[string]*OpenAPIResponse),
+	}
+
+	requestType := derefType(route.info.requestType)
+	if requestType.Kind() == reflect.Struct {
+		body := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+		builder.collectRequest(requestType, operation, body)
+
+		// Path param is required in openapi
+		for _, segment := range splitPath(route.URL) {
+			kind, name := parseSegment(segment)
+			if kind == segmentKind_Static || hasParameter(operation, name, "path") {
+				continue
+			}
+			operation.Parameters = append(operation.Parameters, &OpenAPIParameter{Name: name, In: "path", Required: true, Schema: &OpenAPISchema{Type: "string"}})
+		}
+
+		if len(body.Properties) > 0 && route.Method != http.MethodGet && route.Method != http.MethodHead {
+			operation.RequestBody = &OpenAPIRequestBody{
+				Required: true,
+				Content: map[string]*OpenAPIMediaType{
+					JSON_CONTENT_TYPE:      {Schema: body},
+					FORMDATA_CONTENT_TYPE:  {Schema: body},
+					MULTIPART_CONTENT_TYPE: {Schema: body},
+				},
+			}
+		}
+	}
+
+	data := &OpenAPISchema{}
+	if route.info.responseType != nil {
+		data = builder.schemaOf(route.info.responseType)
+	}
+	success := envelopeSchema(data)
+	operation.Responses[strconv.Itoa(http.StatusOK)] = &OpenAPIResponse{
+		Description: "Success",
+		Content: map[string]*OpenAPIMediaType{
+			success.SuccessContentType: {Schema: success.Success},
+		},
+	}
+
+	errorContent := map[string]*OpenAPIMediaType{
+		builder.errorContentType: {Schema: &OpenAPISchema{Ref: OPENAPI_SCHEMA_REF_PREFIX + OPENAPI_ERROR_RESPONSE_SCHEMA}},
+	}
+	operation.Responses[strconv.Itoa(http.StatusBadRequest)] = &OpenAPIResponse{
+		Description: fmt.Sprintf("Bad request, code: %d (request body is invalid), %d (bind path, query, header or form fail)", ERROR_BAD_BODY_REQUEST, ERROR_BIND_REQUEST_FAIL),
+		Content:     errorContent,
+	}
+	operation.Responses["default"] = &OpenAPIResponse{
+		Description: "Error",
+		Content:     errorContent,
+	}
+
+	return operation
+}
+
+/*
+* collectRequest: fields with path, query, header tags become parameters, other fields become body properties
+ */
+func (builder *openAPIBuilder) collectRequest(t reflect.Type, operation *OpenAPIOperation, body *OpenAPISchema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && derefType(field.Type).Kind() == reflect.Struct {
+			builder.collectRequest(derefType(field.Type), operation, body)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		schema := builder.schemaOf(field.Type)
+		required := applyValidateTag(schema, field.Tag.Get("validate"))
+
+		isParameter := false
+		for _, source := range []string{BIND_TAG_PATH, BIND_TAG_QUERY, BIND_TAG_HEADER} {
+			name := field.Tag.Get(source)
+			if name == BLANK || name == "-" {
+				continue
+			}
+			isParameter = true
+			operation.Parameters = append(operation.Parameters, &OpenAPIParameter{
+				Name:     name,
+				In:       source,
+				Required: required || source == BIND_TAG_PATH,
+				Schema:   schema,
+			})
+		}
+		if isParameter {
+			continue
+		}
+
+		name := jsonFieldName(field)
+		if formName := field.Tag.Get(BIND_TAG_FORM); formName != BLANK && formName != "-" {
+			name = formName
+		}
+		if fileName := field.Tag.Get(BIND_TAG_FILE); fileName != BLANK && fileName != "-" {
+			name = fileName
+		}
+		if name == BLANK {
+			continue
+		}
+		body.Properties[name] = schema
+		if required {
+			body.Required = append(body.Required, name)
+		}
+	}
+}
+
+/*
+* schemaOf: build schema of a type, named struct is put to components and referred by $ref
+ */
+func (builder *openAPIBuilder) schemaOf(t reflect.Type) *OpenAPISchema {
+	t = derefType(t)
+
+	switch t {
+	case timeType:
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &OpenAPISchema{Type: "string", Description: "Duration, example: 1h30m"}
+	case fileHeaderType.Elem():
+		return &OpenAPISchema{Type: "string", Format: "binary"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: builder.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: builder.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == BLANK {
+			return builder.structSchema(t)
+		}
+		return &OpenAPISchema{Ref: OPENAPI_SCHEMA_REF_PREFIX + builder.componentName(t)}
+	}
+
+	// interface, any
+	return &OpenAPISchema{}
+}
+
+/*
+* componentName: get name of struct in components, build its schema if it is not built
+ */
+func (builder *openAPIBuilder) componentName(t reflect.Type) string {
+	if name, ok := builder.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, existed := builder.document.Components.Schemas[name]; existed {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	builder.names[t] = name
+	// Reserve name before building schema, struct may refer to itself
+	builder.document.Components.Schemas[name] = nil
+	builder.document.Components.Schemas[name] = builder.structSchema(t)
+	return name
+}
+
+func (builder *openAPIBuilder) structSchema(t reflect.Type) *OpenAPISchema {
+	schema := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+	builder.collectProperties(t, schema)
+	return schema
+}
+
+func (builder *openAPIBuilder) collectProperties(t reflect.Type, schema *OpenAPISchema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && derefType(field.Type).Kind() == reflect.Struct && field.Tag.Get("json") == BLANK {
+			builder.collectProperties(derefType(field.Type), schema)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := jsonFieldName(field)
+		if name == BLANK {
+			continue
+		}
+
+		property := builder.schemaOf(field.Type)
+		if applyValidateTag(property, field.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+/*
+* applyValidateTag: convert validate tag of go-playground/validator to constraints of schema
+* @return bool: true if field is required
+ */
+func applyValidateTag(schema *OpenAPISchema, tag string) bool {
+	if tag == BLANK || tag == "-" {
+		return false
+	}
+
+	// Constraints are not applied for $ref schema
+	if schema.Ref != BLANK {
+		return strings.Contains(tag, "required")
+	}
+
+	required := false
+	rules := strings.Split(tag, ",")
+	for i, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			// Rules after dive are applied for elements
+			if schema.Items != nil {
+				applyValidateTag(schema.Items, strings.Join(rules[i+1:], ","))
+			}
+			return required
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "url", "uri":
+			schema.Format = "uri"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		case "ip", "ipv4":
+			schema.Format = "ipv4"
+		case "ipv6":
+			schema.Format = "ipv6"
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, enumValue(schema.Type, value))
+			}
+		case "min", "gte":
+			setLowerBound(schema, param, false)
+		case "max", "lte":
+			setUpperBound(schema, param, false)
+		case "gt":
+			setLowerBound(schema, param, true)
+		case "lt":
+			setUpperBound(schema, param, true)
+		case "len":
+			setLowerBound(schema, param, false)
+			setUpperBound(schema, param, false)
+		}
+	}
+
+	return required
+}
+
+func setLowerBound(schema *OpenAPISchema, param string, exclusive bool) {
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	switch schema.Type {
+	case "string":
+		length := int(value)
+		if exclusive {
+			length++
+		}
+		schema.MinLength = &length
+	case "array":
+		length := int(value)
+		if exclusive {
+			length++
+		}
+		schema.MinItems = &length
+	case "integer", "number":
+		if exclusive {
+			schema.ExclusiveMinimum = &value
+		} else {
+			schema.Minimum = &value
+		}
+	}
+}
+
+func setUpperBound(schema *OpenAPISchema, param string, exclusive bool) {
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	switch schema.Type {
+	case "string":
+		length := int(value)
+		if exclusive {
+			length--
+		}
+		schema.MaxLength = &length
+	case "array":
+		length := int(value)
+		if exclusive {
+			length--
+		}
+		schema.MaxItems = &length
+	case "integer", "number":
+		if exclusive {
+			schema.ExclusiveMaximum = &value
+		} else {
+			schema.Maximum = &value
+		}
+	}
+}
+
+func enumValue(schemaType string, value string) any {
+	switch schemaType {
+	case "integer":
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+/*
+* envelopeSchema: schemas of success and error body that are written by envelope of all apis
+* @params: dataSchema *OpenAPISchema (schema of response data of api)
+* @return: EnvelopeSchema
+ */
+func envelopeSchema(dataSchema *OpenAPISchema) EnvelopeSchema {
+	if envelope, ok := responseEnvelope.(ResponseEnvelopeSchema); ok {
+		return envelope.Schema(dataSchema)
+	}
+	return EnvelopeSchema{
+		SuccessContentType: JSON_CONTENT_TYPE,
+		Success:            &OpenAPISchema{Description: "Body is built by custom response envelope"},
+		ErrorContentType:   JSON_CONTENT_TYPE,
+		Error:              &OpenAPISchema{Description: "Body is built by custom response envelope"},
+	}
+}
+
+/*
+* jsonFieldName: get name of field in json, return blank if field is ignored
+ */
+func jsonFieldName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return BLANK
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	if name == BLANK {
+		return field.Name
+	}
+	return name
+}
+
+func hasParameter(operation *OpenAPIOperation, name string, in string) bool {
+	for _, parameter := range operation.Parameters {
+		if parameter.Name == name && parameter.In == in {
+			return true
+		}
+	}
+	return false
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
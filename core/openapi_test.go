@@ -0,0 +1,126 @@
+package core
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type openAPITestRequest struct {
+	Id    string   `path:"id"`
+	Page  int      `query:"page" validate:"min=1"`
+	Name  string   `json:"name" validate:"required,min=3,max=20"`
+	Email string   `json:"email" validate:"omitempty,email"`
+	Kind  string   `json:"kind" validate:"oneof=user admin"`
+	Tags  []string `json:"tags" validate:"max=5,dive,min=2"`
+}
+
+type openAPITestResponse struct {
+	Id       string                 `json:"id"`
+	Children []*openAPITestResponse `json:"children"`
+}
+
+func TestBuildOpenAPIDocument_Success(t *testing.T) {
+	oldRouteMap := routeMap
+	defer func() { routeMap = oldRouteMap }()
+	routeMap = map[string][]Route{
+		"/items/{id}": {
+			{
+				URL:    "/items/{id}",
+				Method: http.MethodPut,
+				info: &routeInfo{
+					requestType:  reflect.TypeOf(&openAPITestRequest{}),
+					responseType: reflect.TypeOf(&openAPITestResponse{}),
+				},
+			},
+		},
+	}
+
+	document := BuildOpenAPIDocument()
+	operation := document.Paths["/items/{id}"]["put"]
+	if operation == nil {
+		t.Fatalf("Expected operation put /items/{id}, got nil")
+	}
+
+	if len(operation.Parameters) != 2 {
+		t.Fatalf("Expected 2 parameters, got %d", len(operation.Parameters))
+	}
+	if page := operation.Parameters[1]; page.In != "query" || page.Schema.Minimum == nil || *page.Schema.Minimum != 1 {
+		t.Errorf("Expected query param page with minimum 1, got %+v", page)
+	}
+
+	body := operation.RequestBody.Content[JSON_CONTENT_TYPE].Schema
+	if _, ok := body.Properties["id"]; ok {
+		t.Errorf("Expected path param is not in body")
+	}
+	if name := body.Properties["name"]; name == nil || *name.MinLength != 3 || *name.MaxLength != 20 {
+		t.Errorf("Expected name with minLength 3, maxLength 20, got %+v", name)
+	}
+	if len(body.Required) != 1 || body.Required[0] != "name" {
+		t.Errorf("Expected required [name], got %v", body.Required)
+	}
+	if body.Properties["email"].Format != "email" {
+		t.Errorf("Expected email format, got %s", body.Properties["email"].Format)
+	}
+	if len(body.Properties["kind"].Enum) != 2 {
+		t.Errorf("Expected 2 enum values, got %v", body.Properties["kind"].Enum)
+	}
+	if tags := body.Properties["tags"]; *tags.MaxItems != 5 || *tags.Items.MinLength != 2 {
+		t.Errorf("Expected tags with maxItems 5 and item minLength 2, got %+v", tags)
+	}
+
+	data := operation.Responses["200"].Content[JSON_CONTENT_TYPE].Schema.Properties["data"]
+	if data.Ref != OPENAPI_SCHEMA_REF_PREFIX+"openAPITestResponse" {
+		t.Errorf("Expected response refer to openAPITestResponse, got %s", data.Ref)
+	}
+	if document.Components.Schemas["openAPITestResponse"] == nil {
+		t.Errorf("Expected openAPITestResponse in components")
+	}
+}
+
+func TestToOpenAPIPath(t *testing.T) {
+	if path := toOpenAPIPath("/files/{dir}/*rest"); path != "/files/{dir}/{rest}" {
+		t.Errorf("Expected /files/{dir}/{rest}, got %s", path)
+	}
+}
+
+func TestBuildOpenAPIDocument_ResponseEnvelope(t *testing.T) {
+	oldRouteMap, oldEnvelope := routeMap, responseEnvelope
+	defer func() { routeMap, responseEnvelope = oldRouteMap, oldEnvelope }()
+	routeMap = map[string][]Route{
+		"/items/{id}": {
+			{
+				URL:    "/items/{id}",
+				Method: http.MethodGet,
+				info: &routeInfo{
+					requestType:  reflect.TypeOf(&openAPITestRequest{}),
+					responseType: reflect.TypeOf(&openAPITestResponse{}),
+				},
+			},
+		},
+	}
+	SetResponseEnvelope(ProblemEnvelope{})
+
+	document := BuildOpenAPIDocument()
+	operation := document.Paths["/items/{id}"]["get"]
+	success := operation.Responses["200"].Content[JSON_CONTENT_TYPE]
+	if success == nil || success.Schema.Ref != OPENAPI_SCHEMA_REF_PREFIX+"openAPITestResponse" {
+		t.Errorf("Expected data without wrapper, got %+v", success)
+	}
+	failure := operation.Responses["default"].Content[PROBLEM_JSON_CONTENT_TYPE]
+	if failure == nil || failure.Schema.Ref != OPENAPI_SCHEMA_REF_PREFIX+OPENAPI_ERROR_RESPONSE_SCHEMA {
+		t.Fatalf("Expected problem json error, got %+v", operation.Responses["default"].Content)
+	}
+	if _, ok := document.Components.Schemas[OPENAPI_ERROR_RESPONSE_SCHEMA].Properties["title"]; !ok {
+		t.Errorf("Expected error schema of problem details, got %+v", document.Components.Schemas[OPENAPI_ERROR_RESPONSE_SCHEMA])
+	}
+
+	SetResponseEnvelope(BareEnvelope{})
+	operation = BuildOpenAPIDocument().Paths["/items/{id}"]["get"]
+	if success := operation.Responses["200"].Content[JSON_CONTENT_TYPE]; success == nil || success.Schema.Properties["data"] != nil {
+		t.Errorf("Expected data without wrapper, got %+v", success)
+	}
+	if operation.Responses["default"].Content[JSON_CONTENT_TYPE] == nil {
+		t.Errorf("Expected json error of default envelope, got %+v", operation.Responses["default"].Content)
+	}
+}
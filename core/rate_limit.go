@@ -0,0 +1,427 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	RATE_LIMIT_KEY_IP       = "ip"
+	RATE_LIMIT_KEY_API_KEY  = "api_key"
+	RATE_LIMIT_KEY_ROUTE    = "route"
+	RATE_LIMIT_STORE_MEMORY = "memory"
+	RATE_LIMIT_STORE_REDIS  = "redis"
+	RATE_LIMIT_ALL_ROUTES   = "*"
+	DEFAULT_API_KEY_HEADER  = "X-Api-Key"
+	RATE_LIMIT_TEMPLATE_KEY = "RATE_LIMIT:%s"
+	// Buckets that are not used in this time are removed from memory store
+	RATE_LIMIT_SWEEP_INTERVAL = time.Minute
+)
+
+/*
+* RateLimit: token bucket, it is refilled with Limit tokens every Period and holds at most Burst tokens
+* A request takes a token, it is rejected when bucket is empty
+ */
+type RateLimit struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+/*
+* RateLimitResult: state of bucket after a request takes a token
+ */
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // Time until a token is available, it is 0 if request is allowed
+	Reset      time.Duration // Time until bucket is full
+}
+
+/*
+* RateLimitStore: store of token buckets
+* TakeAll takes a token from each bucket only if all buckets have a token, it is checked and taken atomically
+* Result of each bucket is allowed if bucket has a token, tokens are not taken if any bucket is empty
+ */
+type RateLimitStore interface {
+	Take(ctx *Context, key string, limit RateLimit) (RateLimitResult, error)
+	TakeAll(ctx *Context, keys []string, limits []RateLimit) ([]RateLimitResult, error)
+}
+
+/*
+* RateLimitKey: get key of bucket from request, requests that have same key share a bucket
+ */
+type RateLimitKey func(ctx *Context) string
+
+/*
+* RateLimitKeyIP: a bucket for each client ip
+ */
+func RateLimitKeyIP(ctx *Context) string {
+	return "ip:" + ctx.GetRemoteIP()
+}
+
+/*
+* RateLimitKeyAPIKey: a bucket for each api key in header, client ip is used if header is not set
+* @params: header string (header that carries api key)
+* @return: RateLimitKey
+ */
+func RateLimitKeyAPIKey(header string) RateLimitKey {
+	return func(ctx *Context) string {
+		apiKey := ctx.GetRequestHeader(header)
+		if apiKey == BLANK {
+			return RateLimitKeyIP(ctx)
+		}
+		return "key:" + apiKey
+	}
+}
+
+/*
+* RateLimitKeyRoute: a bucket for each api, it is shared by all clients
+ */
+func RateLimitKeyRoute(ctx *Context) string {
+	return "route:" + ctx.Method + " " + ctx.route
+}
+
+/*
+* RateLimiter: middleware reject request with 429 when bucket of request is empty
+* RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset headers are set for all requests
+* and Retry-After is set for rejected request. Request is allowed if store fails
+* @params: store RateLimitStore, limit RateLimit (limit must be positive), key RateLimitKey
+* @return: Middleware
+ */
+func RateLimiter(store RateLimitStore, limit RateLimit, key RateLimitKey) Middleware {
+	if limit.Limit <= 0 {
+		LoggerInstance.Panic("Rate limit is invalid: limit must be positive")
+	}
+	limit = limit.normalize()
+	return func(ctx *Context) HttpError {
+		result, err := store.Take(ctx, key(ctx), limit)
+		if err != nil {
+			ctx.LogError("Rate limit fail: %s", err.Error())
+			ctx.Next()
+			return nil
+		}
+
+		ctx.setRateLimitHeaders(limit, result)
+		if !result.Allowed {
+			return HTTP_ERROR_TOO_MANY_REQUESTS
+		}
+		ctx.Next()
+		return nil
+	}
+}
+
+func (limit RateLimit) normalize() RateLimit {
+	if limit.Period <= 0 {
+		limit.Period = time.Second
+	}
+	if limit.Burst <= 0 {
+		limit.Burst = limit.Limit
+	}
+	return limit
+}
+
+/*
+* rate: tokens are refilled in a second
+ */
+func (limit RateLimit) rate() float64 {
+	return float64(limit.Limit) / limit.Period.Seconds()
+}
+
+/*
+* newRateLimitResult: build result from tokens in bucket after request takes a token
+ */
+func newRateLimitResult(allowed bool, tokens float64, limit RateLimit) RateLimitResult {
+	rate := limit.rate()
+	result := RateLimitResult{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit.Burst) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return result
+}
+
+func (ctx *Context) setRateLimitHeaders(limit RateLimit, result RateLimitResult) {
+	header := ctx.rw.Header()
+	header.Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	if !result.Allowed {
+		header.Set("Retry-After", strconv.Itoa(int(math.Max(1, float64(ceilSeconds(result.RetryAfter))))))
+	}
+}
+
+func ceilSeconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
+
+/*
+* memoryRateLimitStore: token buckets in memory of instance
+ */
+type memoryRateLimitStore struct {
+	mutex     sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	full      time.Time // Time that bucket is full, bucket can be removed after it
+}
+
+/*
+* NewMemoryRateLimitStore: create store that keeps buckets in memory, limits are not shared between instances
+* @return: RateLimitStore
+ */
+func NewMemoryRateLimitStore() RateLimitStore {
+	return &memoryRateLimitStore{
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (store *memoryRateLimitStore) Take(ctx *Context, key string, limit RateLimit) (RateLimitResult, error) {
+	results, err := store.TakeAll(ctx, []string{key}, []RateLimit{limit})
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	return results[0], nil
+}
+
+func (store *memoryRateLimitStore) TakeAll(ctx *Context, keys []string, limits []RateLimit) ([]RateLimitResult, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := store.now()
+	store.sweep(now)
+	buckets := make([]*tokenBucket, len(keys))
+	allowed := true
+	for i, key := range keys {
+		buckets[i] = store.refill(key, limits[i], now)
+		allowed = allowed && buckets[i].tokens >= 1
+	}
+
+	results := make([]RateLimitResult, len(keys))
+	for i, bucket := range buckets {
+		if allowed {
+			bucket.tokens--
+		}
+		results[i] = newRateLimitResult(bucket.tokens >= 1 || allowed, bucket.tokens, limits[i])
+		bucket.full = now.Add(results[i].Reset)
+	}
+	return results, nil
+}
+
+/*
+* refill: get bucket of key and add tokens that are refilled since it is updated
+ */
+func (store *memoryRateLimitStore) refill(key string, limit RateLimit, now time.Time) *tokenBucket {
+	bucket, ok := store.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(limit.Burst), updatedAt: now}
+		store.buckets[key] = bucket
+	}
+
+	elapsed := now.Sub(bucket.updatedAt).Seconds()
+	bucket.tokens = math.Min(float64(limit.Burst), bucket.tokens+math.Max(0, elapsed)*limit.rate())
+	bucket.updatedAt = now
+	return bucket
+}
+
+/*
+* sweep: remove buckets that are full, they are same as new buckets
+ */
+func (store *memoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(store.lastSweep) < RATE_LIMIT_SWEEP_INTERVAL {
+		return
+	}
+	store.lastSweep = now
+	for key, bucket := range store.buckets {
+		if !now.Before(bucket.full) {
+			delete(store.buckets, key)
+		}
+	}
+}
+
+/*
+* redisRateLimitStore: token buckets in redis, limits are shared between instances
+* Bucket is updated by lua script, so requests of instances do not overwrite each other
+ */
+type redisRateLimitStore struct {
+	client cacheClient
+}
+
+// KEYS: keys of buckets, ARGV: now in milliseconds, then tokens per millisecond and burst of each bucket
+// Tokens are taken only if all buckets have a token
+var rateLimitScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local tokens = {}
+local allowed = 1
+for i, key in ipairs(KEYS) do
+	local rate = tonumber(ARGV[i * 2])
+	local burst = tonumber(ARGV[i * 2 + 1])
+	local state = redis.call('HMGET', key, 'tokens', 'ts')
+	local current = tonumber(state[1])
+	local ts = tonumber(state[2])
+	if current == nil or ts == nil then
+		current = burst
+		ts = now
+	end
+	tokens[i] = math.min(burst, current + math.max(0, now - ts) * rate)
+	if tokens[i] < 1 then
+		allowed = 0
+	end
+end
+local result = {allowed}
+for i, key in ipairs(KEYS) do
+	local rate = tonumber(ARGV[i * 2])
+	local burst = tonumber(ARGV[i * 2 + 1])
+	if allowed == 1 then
+		tokens[i] = tokens[i] - 1
+	end
+	redis.call('HSET', key, 'tokens', tostring(tokens[i]), 'ts', now)
+	redis.call('PEXPIRE', key, math.ceil(burst / rate) + 1000)
+	result[i + 1] = tostring(tokens[i])
+end
+return result
+`)
+
+/*
+* NewRedisRateLimitStore: create store that keeps buckets in redis
+* @params: client cacheClient
+* @return: RateLimitStore
+ */
+func NewRedisRateLimitStore(client cacheClient) RateLimitStore {
+	return &redisRateLimitStore{client: client}
+}
+
+func (store *redisRateLimitStore) Take(ctx *Context, key string, limit RateLimit) (RateLimitResult, error) {
+	results, err := store.TakeAll(ctx, []string{key}, []RateLimit{limit})
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	return results[0], nil
+}
+
+func (store *redisRateLimitStore) TakeAll(ctx *Context, keys []string, limits []RateLimit) ([]RateLimitResult, error) {
+	bucketKeys := make([]string, len(keys))
+	args := []any{time.Now().UnixMilli()}
+	for i, key := range keys {
+		bucketKeys[i] = fmt.Sprintf(RATE_LIMIT_TEMPLATE_KEY, key)
+		args = append(args, limits[i].rate()/1000, limits[i].Burst)
+	}
+	values, err := rateLimitScript.Run(ctx, store.client.Client, bucketKeys, args...).Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != len(keys)+1 {
+		return nil, fmt.Errorf("unexpected result of rate limit script: %v", values)
+	}
+
+	allowed, _ := values[0].(int64)
+	results := make([]RateLimitResult, len(keys))
+	for i := range keys {
+		tokensValue, _ := values[i+1].(string)
+		tokens, err := strconv.ParseFloat(tokensValue, 64)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = newRateLimitResult(allowed == 1 || tokens >= 1, tokens, limits[i])
+	}
+	return results, nil
+}
+
+/*
+* initRateLimit: use rate limit middleware with rules in config
+* Redis is connected if store is redis and it is not connected before
+ */
+func initRateLimit() {
+	var store RateLimitStore
+	if Config.RateLimit.Store == RATE_LIMIT_STORE_REDIS {
+		if redisClient.Client == nil {
+			redisClient = connectCacheDB()
+		}
+		store = NewRedisRateLimitStore(redisClient)
+	} else {
+		store = NewMemoryRateLimitStore()
+	}
+	UseMiddleware(rateLimitByRules(store, Config.RateLimit.Rules))
+}
+
+/*
+* rateLimitByRules: middleware apply rules that match route of request, rule with route * matches all routes
+* Tokens are taken from buckets of all rules only if all rules allow request, so rejected request doesn't use up other rules
+* Headers of the rule that rejects request or the rule that has the fewest remaining tokens are set
+ */
+func rateLimitByRules(store RateLimitStore, rules []RateLimitRule) Middleware {
+	type rateLimitRule struct {
+		route string
+		limit RateLimit
+		key   RateLimitKey
+	}
+	routeRules := make(map[string][]rateLimitRule)
+	for _, rule := range rules {
+		if rule.Limit <= 0 {
+			LoggerInstance.Warning("Rate limit rule of route %s is ignored: limit must be positive", rule.Route)
+			continue
+		}
+		routeRules[rule.Route] = append(routeRules[rule.Route], rateLimitRule{
+			route: rule.Route,
+			limit: rule.GetRateLimit(),
+			key:   rule.GetKey(),
+		})
+	}
+	// Rules of all routes are appended to rules of each route here, so slices are only read by requests
+	allRouteRules := routeRules[RATE_LIMIT_ALL_ROUTES]
+	for route, matched := range routeRules {
+		if route != RATE_LIMIT_ALL_ROUTES {
+			routeRules[route] = append(matched[:len(matched):len(matched)], allRouteRules...)
+		}
+	}
+
+	return func(ctx *Context) HttpError {
+		matched, ok := routeRules[ctx.route]
+		if !ok {
+			matched = allRouteRules
+		}
+		if len(matched) == 0 {
+			ctx.Next()
+			return nil
+		}
+		keys := make([]string, len(matched))
+		limits := make([]RateLimit, len(matched))
+		for i, rule := range matched {
+			keys[i], limits[i] = rule.route+"|"+rule.key(ctx), rule.limit
+		}
+		results, err := store.TakeAll(ctx, keys, limits)
+		if err != nil {
+			ctx.LogError("Rate limit fail: %s", err.Error())
+			ctx.Next()
+			return nil
+		}
+
+		limit, result := limits[0], results[0]
+		for i, current := range results {
+			if result.Allowed && (!current.Allowed || current.Remaining < result.Remaining) {
+				limit, result = limits[i], current
+			}
+		}
+		ctx.setRateLimitHeaders(limit, result)
+		if !result.Allowed {
+			return HTTP_ERROR_TOO_MANY_REQUESTS
+		}
+		ctx.Next()
+		return nil
+	}
+}
@@ -0,0 +1,171 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newRateLimitTestContext(remoteAddr string, route string) (*Context, *httptest.ResponseRecorder) {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	request.RemoteAddr = remoteAddr
+	ctx := &Context{Context: context.Background(), Method: http.MethodGet, request: request, route: route, requestID: "1", responseMediaType: JSON_CONTENT_TYPE}
+	ctx.response = responseWriter{ResponseWriter: recorder}
+	ctx.rw = &ctx.response
+	return ctx, recorder
+}
+
+func TestRateLimit_MemoryStoreRefill(t *testing.T) {
+	now := time.Unix(1000, 0)
+	store := NewMemoryRateLimitStore().(*memoryRateLimitStore)
+	store.now = func() time.Time { return now }
+	limit := RateLimit{Limit: 2, Period: time.Second, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		if result, _ := store.Take(nil, "a", limit); !result.Allowed {
+			t.Fatalf("Expected request %d is allowed", i)
+		}
+	}
+	result, _ := store.Take(nil, "a", limit)
+	if result.Allowed || result.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("Expected rejected with retry after 500ms, got %+v", result)
+	}
+	if result, _ := store.Take(nil, "b", limit); !result.Allowed {
+		t.Errorf("Expected other key has its own bucket")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if result, _ := store.Take(nil, "a", limit); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("Expected a token is refilled, got %+v", result)
+	}
+}
+
+func TestRateLimit_MemoryStoreSweep(t *testing.T) {
+	now := time.Unix(1000, 0)
+	store := NewMemoryRateLimitStore().(*memoryRateLimitStore)
+	store.now = func() time.Time { return now }
+	store.lastSweep = now
+
+	store.Take(nil, "a", RateLimit{Limit: 1, Period: time.Second, Burst: 1})
+	now = now.Add(RATE_LIMIT_SWEEP_INTERVAL)
+	store.Take(nil, "b", RateLimit{Limit: 1, Period: time.Second, Burst: 1})
+	if _, ok := store.buckets["a"]; ok || len(store.buckets) != 1 {
+		t.Errorf("Expected full bucket is removed, got %d buckets", len(store.buckets))
+	}
+}
+
+func TestRateLimit_MiddlewareRejects(t *testing.T) {
+	middleware := RateLimiter(NewMemoryRateLimitStore(), RateLimit{Limit: 1, Period: time.Minute}, RateLimitKeyIP)
+
+	ctx, recorder := newRateLimitTestContext("10.0.0.1:5000", "/items/{id}")
+	if ctx.runMiddlewares([]Middleware{middleware}) {
+		t.Fatalf("Expected first request is allowed")
+	}
+	if recorder.Header().Get("RateLimit-Remaining") != "0" || recorder.Header().Get("RateLimit-Limit") != "1" {
+		t.Errorf("Unexpected headers: %v", recorder.Header())
+	}
+
+	ctx, recorder = newRateLimitTestContext("10.0.0.1:5001", "/items/{id}")
+	if !ctx.runMiddlewares([]Middleware{middleware}) {
+		t.Fatalf("Expected second request is rejected")
+	}
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429, got %d", recorder.Code)
+	}
+	if recorder.Header().Get("Retry-After") != "60" || recorder.Header().Get("RateLimit-Reset") != "60" {
+		t.Errorf("Unexpected headers: %v", recorder.Header())
+	}
+
+	ctx, _ = newRateLimitTestContext("10.0.0.2:5000", "/items/{id}")
+	if ctx.runMiddlewares([]Middleware{middleware}) {
+		t.Errorf("Expected request of other ip is allowed")
+	}
+}
+
+func TestRateLimit_RulesByRoute(t *testing.T) {
+	middleware := rateLimitByRules(NewMemoryRateLimitStore(), []RateLimitRule{
+		{Route: "/items/{id}", Key: RATE_LIMIT_KEY_API_KEY, Limit: 1, Period: 60},
+		{Route: RATE_LIMIT_ALL_ROUTES, Key: RATE_LIMIT_KEY_ROUTE, Limit: 10, Period: 60},
+	})
+
+	ctx, recorder := newRateLimitTestContext("10.0.0.1:5000", "/items/{id}")
+	ctx.request.Header.Set(DEFAULT_API_KEY_HEADER, "key-1")
+	if ctx.runMiddlewares([]Middleware{middleware}) {
+		t.Fatalf("Expected first request is allowed")
+	}
+	if recorder.Header().Get("RateLimit-Limit") != "1" {
+		t.Errorf("Expected headers of most restrictive rule, got %v", recorder.Header())
+	}
+
+	ctx, recorder = newRateLimitTestContext("10.0.0.1:5000", "/items/{id}")
+	ctx.request.Header.Set(DEFAULT_API_KEY_HEADER, "key-1")
+	if !ctx.runMiddlewares([]Middleware{middleware}) || recorder.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 for same api key, got %d", recorder.Code)
+	}
+
+	ctx, _ = newRateLimitTestContext("10.0.0.1:5000", "/items/{id}")
+	ctx.request.Header.Set(DEFAULT_API_KEY_HEADER, "key-2")
+	if ctx.runMiddlewares([]Middleware{middleware}) {
+		t.Errorf("Expected request of other api key is allowed")
+	}
+
+	ctx, recorder = newRateLimitTestContext("10.0.0.1:5000", "/users")
+	if ctx.runMiddlewares([]Middleware{middleware}) || recorder.Header().Get("RateLimit-Limit") != "10" {
+		t.Errorf("Expected only rule of all routes is applied, got %v", recorder.Header())
+	}
+}
+
+func TestRateLimit_RejectedRequestKeepsTokensOfOtherRules(t *testing.T) {
+	middleware := rateLimitByRules(NewMemoryRateLimitStore(), []RateLimitRule{
+		{Route: "/items/{id}", Key: RATE_LIMIT_KEY_IP, Limit: 3, Period: 60},
+		{Route: RATE_LIMIT_ALL_ROUTES, Key: RATE_LIMIT_KEY_API_KEY, Limit: 1, Period: 60},
+	})
+	request := func(apiKey string) (bool, *httptest.ResponseRecorder) {
+		ctx, recorder := newRateLimitTestContext("10.0.0.1:5000", "/items/{id}")
+		ctx.request.Header.Set(DEFAULT_API_KEY_HEADER, apiKey)
+		return !ctx.runMiddlewares([]Middleware{middleware}), recorder
+	}
+
+	if allowed, _ := request("key-1"); !allowed {
+		t.Fatalf("Expected first request is allowed")
+	}
+	allowed, recorder := request("key-1")
+	if allowed || recorder.Header().Get("RateLimit-Limit") != "1" {
+		t.Fatalf("Expected 429 by rule of api key, got %d %v", recorder.Code, recorder.Header())
+	}
+	// Rejected request doesn't take token of ip rule, so ip has 2 tokens
+	for _, apiKey := range []string{"key-2", "key-3"} {
+		if allowed, recorder := request(apiKey); !allowed {
+			t.Errorf("Expected request of %s is allowed, got %d", apiKey, recorder.Code)
+		}
+	}
+	if allowed, recorder := request("key-4"); allowed || recorder.Header().Get("RateLimit-Limit") != "3" {
+		t.Errorf("Expected 429 by rule of ip, got %d %v", recorder.Code, recorder.Header())
+	}
+}
+
+func TestRateLimit_RulesConcurrentRequests(t *testing.T) {
+	middleware := rateLimitByRules(NewMemoryRateLimitStore(), []RateLimitRule{
+		{Route: "/items/{id}", Key: RATE_LIMIT_KEY_IP, Limit: 100, Period: 60},
+		{Route: "/items/{id}", Key: RATE_LIMIT_KEY_API_KEY, Limit: 100, Period: 60},
+		{Route: "/items/{id}", Key: RATE_LIMIT_KEY_ROUTE, Limit: 100, Period: 60},
+		{Route: RATE_LIMIT_ALL_ROUTES, Key: RATE_LIMIT_KEY_IP, Limit: 100, Period: 60},
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, recorder := newRateLimitTestContext("10.0.0.1:5000", "/items/{id}")
+			if ctx.runMiddlewares([]Middleware{middleware}) || recorder.Header().Get("RateLimit-Limit") != "100" {
+				t.Errorf("Expected request is allowed, got %d %v", recorder.Code, recorder.Header())
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"net/http"
+	"runtime/debug"
+)
+
+/*
+* PanicHandler: hook is called when a handler or middleware panics
+* It is used to report panic to an error sink (sentry, slack, ...), response is written by core
+ */
+type PanicHandler func(ctx *Context, recovered any, stack []byte)
+
+var panicHandler PanicHandler
+
+/*
+* SetPanicHandler: set hook that is called when a handler or middleware panics
+* @params: handler PanicHandler
+* @return: void
+ */
+func SetPanicHandler(handler PanicHandler) {
+	panicHandler = handler
+}
+
+/*
+* recoverPanic: recover panic of handler and middlewares, log stack and write 500 response
+* It must be deferred after putContext, so context is returned to pool after recovering
+* http.ErrAbortHandler is panicked again to abort response as net/http does
+ */
+func (ctx *Context) recoverPanic() {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	if recovered == http.ErrAbortHandler {
+		panic(recovered)
+	}
+
+	stack := debug.Stack()
+	ctx.LogError("Panic: %v\n%s", recovered, stack)
+	ctx.reportPanic(recovered, stack)
+	ctx.writeError(HTTP_ERROR_INTERNAL_SERVER_ERROR)
+}
+
+/*
+* reportPanic: call panic handler, panic of the hook is logged and ignored
+ */
+func (ctx *Context) reportPanic(recovered any, stack []byte) {
+	if panicHandler == nil {
+		return
+	}
+
+	defer func() {
+		if err := recover(); err != nil {
+			ctx.LogError("Panic handler panics: %v", err)
+		}
+	}()
+	panicHandler(ctx, recovered, stack)
+}
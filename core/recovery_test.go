@@ -0,0 +1,44 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecoverPanic_WriteInternalServerError(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	ctx := &Context{rw: recorder, requestID: "1"}
+
+	var reported any
+	SetPanicHandler(func(ctx *Context, recovered any, stack []byte) {
+		reported = recovered
+	})
+	defer SetPanicHandler(nil)
+
+	func() {
+		defer ctx.recoverPanic()
+		panic("handler fail")
+	}()
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", recorder.Code)
+	}
+	if reported != "handler fail" {
+		t.Errorf("Expected panic is reported, got %v", reported)
+	}
+}
+
+func TestRecoverPanic_AbortHandler(t *testing.T) {
+	ctx := &Context{rw: httptest.NewRecorder(), requestID: "1"}
+
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Errorf("Expected http.ErrAbortHandler, got %v", recovered)
+		}
+	}()
+	func() {
+		defer ctx.recoverPanic()
+		panic(http.ErrAbortHandler)
+	}()
+}
@@ -0,0 +1,140 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	REQUEST_ID_HEADER  = "X-Request-Id"
+	TRACEPARENT_HEADER = "traceparent"
+)
+
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9\-_.:]{1,128}$`)
+
+// W3C trace context: version-traceid-parentid-flags
+var traceparentPattern = regexp.MustCompile(`^00-([0-9a-f]{32})-([0-9a-f]{16})-[0-9a-f]{2}$`)
+
+/*
+* GetRequestID: id of request, it is received from request id header or generated by core
+* @return: string
+ */
+func (ctx *Context) GetRequestID() string {
+	return ctx.requestID
+}
+
+/*
+* isTraceparent: check if request id is propagated by W3C traceparent header
+ */
+func isTraceparent() bool {
+	return strings.EqualFold(Config.RequestID.GetHeader(), TRACEPARENT_HEADER)
+}
+
+/*
+* initRequestID: use request id from header of request if it is valid, otherwise generate a new one
+ */
+func (ctx *Context) initRequestID(request *http.Request) {
+	value := request.Header.Get(Config.RequestID.GetHeader())
+	if requestID, ok := parseRequestID(value); ok {
+		ctx.requestID = requestID
+		return
+	}
+
+	ctx.requestID = newRequestID()
+	if value != BLANK {
+		ctx.LogDebug("Request id in header is invalid: %s", value)
+	}
+}
+
+/*
+* parseRequestID: validate value of request id header
+* Trace id is used as request id when header is traceparent
+* @return: string (request id), bool (valid or not)
+ */
+func parseRequestID(value string) (string, bool) {
+	if isTraceparent() {
+		matches := traceparentPattern.FindStringSubmatch(value)
+		if matches == nil || isZeroHex(matches[1]) || isZeroHex(matches[2]) {
+			return BLANK, false
+		}
+		return matches[1], true
+	}
+
+	if !requestIDPattern.MatchString(value) {
+		return BLANK, false
+	}
+	return value, true
+}
+
+/*
+* newRequestID: generate request id, it is a random trace id when header is traceparent
+ */
+func newRequestID() string {
+	if isTraceparent() {
+		return randomHex(16)
+	}
+	return ID.GenerateID()
+}
+
+/*
+* requestIDHeaderValue: value of request id header that is sent to other services
+* A new parent id is generated for traceparent header
+ */
+func (ctx *Context) requestIDHeaderValue() string {
+	if isTraceparent() {
+		return fmt.Sprintf("00-%s-%s-01", ctx.requestID, randomHex(8))
+	}
+	return ctx.requestID
+}
+
+/*
+* propagateRequestID: set request id header of outgoing http request
+* Request id of core context is not propagated, header that is set by user is kept
+ */
+func (ctx *Context) propagateRequestID(header http.Header) {
+	if ctx == nil || ctx == coreContext || ctx.requestID == BLANK {
+		return
+	}
+	if header.Get(Config.RequestID.GetHeader()) == BLANK {
+		header.Set(Config.RequestID.GetHeader(), ctx.requestIDHeaderValue())
+	}
+}
+
+/*
+* messageHeaders: headers of message that is published to message queue
+ */
+func (ctx *Context) messageHeaders() amqp.Table {
+	if ctx == nil || ctx == coreContext || ctx.requestID == BLANK {
+		return nil
+	}
+	return amqp.Table{Config.RequestID.GetHeader(): ctx.requestIDHeaderValue()}
+}
+
+/*
+* requestIDFromMessage: get request id from headers of consumed message
+* @return: string (blank if message has no valid request id)
+ */
+func requestIDFromMessage(headers amqp.Table) string {
+	value, ok := headers[Config.RequestID.GetHeader()].(string)
+	if !ok {
+		return BLANK
+	}
+	requestID, _ := parseRequestID(value)
+	return requestID
+}
+
+func isZeroHex(value string) bool {
+	return strings.Trim(value, "0") == BLANK
+}
+
+func randomHex(size int) string {
+	data := make([]byte, size)
+	rand.Read(data)
+	return hex.EncodeToString(data)
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseRequestID_Header(t *testing.T) {
+	testCases := map[string]bool{
+		"abc-123":                true,
+		"a.b_c:1":                true,
+		BLANK:                    false,
+		"abc 123":                false,
+		"abc\n123":               false,
+		strings.Repeat("a", 129): false,
+	}
+
+	for value, expected := range testCases {
+		if _, ok := parseRequestID(value); ok != expected {
+			t.Errorf("Request id %q: expected %t, got %t", value, expected, ok)
+		}
+	}
+}
+
+func TestParseRequestID_Traceparent(t *testing.T) {
+	Config.RequestID.Header = TRACEPARENT_HEADER
+	defer func() { Config.RequestID.Header = BLANK }()
+
+	requestID, ok := parseRequestID("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if !ok || requestID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected trace id, got %s, %t", requestID, ok)
+	}
+	if _, ok := parseRequestID("00-00000000000000000000000000000000-00f067aa0ba902b7-01"); ok {
+		t.Errorf("Expected zero trace id is invalid")
+	}
+
+	ctx := &Context{requestID: requestID}
+	header := http.Header{}
+	ctx.propagateRequestID(header)
+	value := header.Get(TRACEPARENT_HEADER)
+	if !strings.HasPrefix(value, "00-4bf92f3577b34da6a3ce929d0e0e4736-") || !traceparentPattern.MatchString(value) {
+		t.Errorf("Expected traceparent with same trace id, got %s", value)
+	}
+}
+
+func TestPropagateRequestID_KeepUserHeader(t *testing.T) {
+	ctx := &Context{requestID: "1"}
+	header := http.Header{}
+	header.Set(REQUEST_ID_HEADER, "2")
+	ctx.propagateRequestID(header)
+
+	if value := header.Get(REQUEST_ID_HEADER); value != "2" {
+		t.Errorf("Expected 2, got %s", value)
+	}
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+var errHijackNotSupported = errors.New("response writer does not support hijack")
+
+/*
+* responseWriter: wrap http.ResponseWriter to record status code and bytes of response
+ */
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (rw *responseWriter) WriteHeader(statusCode int) {
+	if rw.status == 0 {
+		rw.status = statusCode
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *responseWriter) Write(data []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(data)
+	rw.size += int64(n)
+	return n, err
+}
+
+/*
+* Flush: flush buffered data to client, it is used by server-sent events
+ */
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		if rw.status == 0 {
+			rw.status = http.StatusOK
+		}
+		flusher.Flush()
+	}
+}
+
+/*
+* Hijack: take over connection, it is used by websocket upgrade
+ */
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errHijackNotSupported
+	}
+	if rw.status == 0 {
+		rw.status = http.StatusSwitchingProtocols
+	}
+	return hijacker.Hijack()
+}
+
+/*
+* Unwrap: original response writer, it is used by http.ResponseController
+ */
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+/*
+* GetResponseStatus: status code of response, 200 if nothing is written
+* @return: int
+ */
+func (ctx *Context) GetResponseStatus() int {
+	if ctx.response.status == 0 {
+		return http.StatusOK
+	}
+	return ctx.response.status
+}
+
+/*
+* GetResponseSize: bytes of response body that is written
+* @return: int64
+ */
+func (ctx *Context) GetResponseSize() int64 {
+	return ctx.response.size
+}
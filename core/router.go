@@ -0,0 +1,391 @@
+package core
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	segmentKind_Static segmentKind = iota
+	segmentKind_Param
+	segmentKind_Wildcard
+)
+
+/*
+* pathParam: a value captured from a {param} or *wildcard segment of url
+ */
+type pathParam struct {
+	key   string
+	value string
+}
+
+type pathParams []pathParam
+
+/*
+* routeInfo: describe request and response of route, it is used to build api document
+* cors is policy of group of route, it is used to answer preflight request
+ */
+type routeInfo struct {
+	requestType  reflect.Type
+	responseType reflect.Type
+	cors         *corsPolicy
+}
+
+/*
+* get: get value of path param by key
+* @params: key string
+* @return: string, bool
+ */
+func (params pathParams) get(key string) (string, bool) {
+	for _, param := range params {
+		if param.key == key {
+			return param.value, true
+		}
+	}
+	return BLANK, false
+}
+
+/*
+* routeNode: a node of route tree, each node is a segment of url
+* Precedence when matching: static segment > {param} segment > *wildcard segment
+ */
+type routeNode struct {
+	segment  string
+	kind     segmentKind
+	pattern  string
+	allow    string
+	routes   []Route
+	static   map[string]*routeNode
+	param    *routeNode
+	wildcard *routeNode
+}
+
+func newRouteNode(segment string, kind segmentKind) *routeNode {
+	return &routeNode{
+		segment: segment,
+		kind:    kind,
+		static:  make(map[string]*routeNode),
+	}
+}
+
+/*
+* splitPath: split url to segments
+* "/" => [], "/items/1" => ["items", "1"], "/items/" => ["items", ""]
+ */
+func splitPath(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	if path == BLANK {
+		return []string{}
+	}
+	return strings.Split(path, "/")
+}
+
+/*
+* parseSegment: get kind and name of a segment in url pattern
+* @params: segment string
+* @return: segmentKind, string
+ */
+func parseSegment(segment string) (segmentKind, string) {
+	if len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+		return segmentKind_Param, segment[1 : len(segment)-1]
+	}
+
+	if len(segment) > 1 && strings.HasPrefix(segment, "*") {
+		return segmentKind_Wildcard, segment[1:]
+	}
+
+	return segmentKind_Static, segment
+}
+
+/*
+* addRoute: add route to tree
+* Return error when url pattern is invalid or conflict with a registered route
+* @params: route Route
+* @return: Error
+ */
+func (root *routeNode) addRoute(route Route) Error {
+	if !strings.HasPrefix(route.URL, "/") {
+		LoggerInstance.Error("Url must start with '/': %s", route.URL)
+		return ERROR_ROUTE_PATTERN_INVALID
+	}
+
+	segments := splitPath(route.URL)
+	node := root
+	for i, segment := range segments {
+		kind, name := parseSegment(segment)
+		if strings.ContainsAny(name, "{}*") {
+			LoggerInstance.Error("Segment %s is invalid in url: %s", segment, route.URL)
+			return ERROR_ROUTE_PATTERN_INVALID
+		}
+
+		switch kind {
+		case segmentKind_Static:
+			child, ok := node.static[name]
+			if !ok {
+				child = newRouteNode(name, kind)
+				node.static[name] = child
+			}
+			node = child
+		case segmentKind_Param:
+			if node.param == nil {
+				node.param = newRouteNode(name, kind)
+			} else if node.param.segment != name {
+				LoggerInstance.Error("Param {%s} of url %s conflict with param {%s} at the same position", name, route.URL, node.param.segment)
+				return ERROR_ROUTE_CONFLICT
+			}
+			node = node.param
+		case segmentKind_Wildcard:
+			if i != len(segments)-1 {
+				LoggerInstance.Error("Wildcard *%s must be the last segment of url: %s", name, route.URL)
+				return ERROR_ROUTE_PATTERN_INVALID
+			}
+			if node.wildcard == nil {
+				node.wildcard = newRouteNode(name, kind)
+			} else if node.wildcard.segment != name {
+				LoggerInstance.Error("Wildcard *%s of url %s conflict with wildcard *%s", name, route.URL, node.wildcard.segment)
+				return ERROR_ROUTE_CONFLICT
+			}
+			node = node.wildcard
+		}
+	}
+
+	for _, registered := range node.routes {
+		if registered.Method == route.Method {
+			LoggerInstance.Error("Route %s %s conflict with registered route %s %s", route.Method, route.URL, registered.Method, registered.URL)
+			return ERROR_ROUTE_CONFLICT
+		}
+	}
+
+	node.pattern = route.URL
+	node.routes = append(node.routes, route)
+	node.allow = buildAllowMethods(node.routes)
+	return nil
+}
+
+/*
+* buildAllowMethods: list methods of routes, duplicated methods are listed once
+* HEAD is accepted if GET is registered, OPTIONS is always accepted
+* @params: routes []Route
+* @return string, example: "GET, POST, HEAD, OPTIONS"
+ */
+func buildAllowMethods(routes []Route) string {
+	methods := []string{}
+	seen := make(map[string]bool, len(routes))
+	hasGet, hasHead, hasOptions := false, false, false
+	for _, route := range routes {
+		if seen[route.Method] {
+			continue
+		}
+		seen[route.Method] = true
+		methods = append(methods, route.Method)
+		switch route.Method {
+		case http.MethodGet:
+			hasGet = true
+		case http.MethodHead:
+			hasHead = true
+		case http.MethodOptions:
+			hasOptions = true
+		}
+	}
+
+	if hasGet && !hasHead {
+		methods = append(methods, http.MethodHead)
+	}
+	if !hasOptions {
+		methods = append(methods, http.MethodOptions)
+	}
+	return strings.Join(methods, ", ")
+}
+
+/*
+* findRoute: find route of node by method
+* HEAD request is handled by GET route if HEAD route is not registered
+* @params: method string
+* @return: *Route (nil if not found)
+ */
+func (node *routeNode) findRoute(method string) *Route {
+	for i := range node.routes {
+		if node.routes[i].Method == method {
+			return &node.routes[i]
+		}
+	}
+
+	if method == http.MethodHead {
+		return node.findRoute(http.MethodGet)
+	}
+	return nil
+}
+
+/*
+* lookup: find node that match with url path and has route of method
+* Node that matches url but doesn't have route of method is only returned if no other node has it
+* Example: POST /items/new and GET /items/{id} are registered, GET /items/new => /items/{id}
+* @params: path string, method string
+* @return: *routeNode, pathParams (nil if not found)
+ */
+func (root *routeNode) lookup(path string, method string) (*routeNode, pathParams) {
+	segments := splitPath(path)
+	if node, params := root.match(segments, nil, method); node != nil {
+		return node, params
+	}
+	return root.match(segments, nil, BLANK)
+}
+
+/*
+* match: find node of segments by precedence, blank method matches node that has any route
+ */
+func (node *routeNode) match(segments []string, params pathParams, method string) (*routeNode, pathParams) {
+	if len(segments) == 0 {
+		if node.hasRoute(method) {
+			return node, params
+		}
+		return nil, nil
+	}
+
+	segment := segments[0]
+	// Static segment
+	if child, ok := node.static[segment]; ok {
+		if found, foundParams := child.match(segments[1:], params, method); found != nil {
+			return found, foundParams
+		}
+	}
+
+	// Param segment
+	if node.param != nil && segment != BLANK {
+		if found, foundParams := node.param.match(segments[1:], append(params, pathParam{key: node.param.segment, value: segment}), method); found != nil {
+			return found, foundParams
+		}
+	}
+
+	// Wildcard segment: get all the rest of url
+	if node.wildcard != nil && node.wildcard.hasRoute(method) {
+		return node.wildcard, append(params, pathParam{key: node.wildcard.segment, value: strings.Join(segments, "/")})
+	}
+
+	return nil, nil
+}
+
+func (node *routeNode) hasRoute(method string) bool {
+	if method == BLANK {
+		return len(node.routes) > 0
+	}
+	return node.findRoute(method) != nil
+}
+
+/*
+* allowMethods: list methods of all routes that match url path, not only the route of node that is found
+* Example: POST /items/new and GET /items/{id} are registered, /items/new allows GET, POST
+* @params: path string
+* @return: string (value of Allow header)
+ */
+func (root *routeNode) allowMethods(path string) string {
+	return buildAllowMethods(root.collectRoutes(splitPath(path), nil))
+}
+
+func (node *routeNode) collectRoutes(segments []string, routes []Route) []Route {
+	if len(segments) == 0 {
+		return append(routes, node.routes...)
+	}
+
+	segment := segments[0]
+	if child, ok := node.static[segment]; ok {
+		routes = child.collectRoutes(segments[1:], routes)
+	}
+	if node.param != nil && segment != BLANK {
+		routes = node.param.collectRoutes(segments[1:], routes)
+	}
+	if node.wildcard != nil {
+		routes = append(routes, node.wildcard.routes...)
+	}
+	return routes
+}
+
+/*
+* registerRoute: save route to routeMap and route tree
+* Panic when url pattern is invalid or conflict with a registered route
+ */
+func registerRoute(url string, method string, handler routeHandler, info *routeInfo) {
+	if info == nil {
+		info = &routeInfo{}
+	}
+	route := Route{
+		Method:  method,
+		URL:     url,
+		handler: handler,
+		info:    info,
+	}
+
+	if err := routeTree.addRoute(route); err != nil {
+		LoggerInstance.Panic("Register api fail: %s %s, err = %s", method, url, err.Error())
+	}
+
+	routeMap[url] = append(routeMap[url], route)
+}
+
+/*
+* notFound: answer 404 to unrouted request, access log is written if it is enabled
+ */
+func notFound(writer http.ResponseWriter, request *http.Request) {
+	if accessLogFormat == BLANK {
+		http.NotFound(writer, request)
+		return
+	}
+	ctx := getContext()
+	defer putContext(ctx)
+	buildContext(ctx, writer, request, nil)
+	ctx.logAccess(accessLogFormat)
+	http.NotFound(ctx.rw, request)
+}
+
+/*
+* serveHTTP: dispatch request to handler of route
+* Not found url => 404, url is found but method is not registered => 405 with Allow header
+* OPTIONS request is answered automatically if OPTIONS route is not registered
+ */
+func serveHTTP(writer http.ResponseWriter, request *http.Request) {
+	node, params := routeTree.lookup(request.URL.Path, request.Method)
+	if node == nil {
+		notFound(writer, request)
+		return
+	}
+	// Preflight request uses route of method that will be requested, so its cors policy is applied
+	if request.Method == http.MethodOptions && node.findRoute(http.MethodOptions) == nil {
+		if method := request.Header.Get("Access-Control-Request-Method"); method != BLANK {
+			if preflightNode, preflightParams := routeTree.lookup(request.URL.Path, method); preflightNode != nil {
+				node, params = preflightNode, preflightParams
+			}
+		}
+	}
+
+	if route := node.findRoute(request.Method); route != nil {
+		route.handler(writer, request, params)
+		return
+	}
+
+	allow := routeTree.allowMethods(request.URL.Path)
+	writer.Header().Set("Allow", allow)
+	ctx := getContext()
+	defer putContext(ctx)
+	defer ctx.recoverPanic()
+	buildContext(ctx, writer, request, params)
+	ctx.instrumentRequest(node.pattern)
+	ctx.allowMethods = allow
+
+	if request.Method != http.MethodOptions {
+		ctx.writeError(HTTP_ERROR_METHOD_NOT_ALLOWED)
+		return
+	}
+
+	// Preflight request: call common middlewares (cors) before answer
+	ctx.corsPolicy = node.findCorsPolicy(request)
+	if ctx.runMiddlewares(commonMiddlewares) {
+		return
+	}
+	if ctx.corsPolicy != nil && ctx.corsPolicy.handle(ctx) {
+		return
+	}
+	ctx.endResponse(http.StatusNoContent, BLANK)
+}
@@ -0,0 +1,230 @@
+package core
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func newTestRouteTree(t *testing.T, urls ...string) *routeNode {
+	root := newRouteNode(BLANK, segmentKind_Static)
+	for _, url := range urls {
+		if err := root.addRoute(Route{URL: url, Method: http.MethodGet}); err != nil {
+			t.Fatalf("Add route %s fail: %s", url, err.Error())
+		}
+	}
+	return root
+}
+
+func TestRouteLookup_StaticRoute(t *testing.T) {
+	root := newTestRouteTree(t, "/items", "/items/new")
+
+	node, params := root.lookup("/items/new", http.MethodGet)
+	if node == nil || node.pattern != "/items/new" {
+		t.Fatalf("Expected /items/new, got %v", node)
+	}
+	if len(params) != 0 {
+		t.Errorf("Expected no params, got %v", params)
+	}
+}
+
+func TestRouteLookup_ParamRoute(t *testing.T) {
+	root := newTestRouteTree(t, "/items/{id}/detail")
+
+	node, params := root.lookup("/items/10/detail", http.MethodGet)
+	if node == nil || node.pattern != "/items/{id}/detail" {
+		t.Fatalf("Expected /items/{id}/detail, got %v", node)
+	}
+	if value, _ := params.get("id"); value != "10" {
+		t.Errorf("Expected id = 10, got %s", value)
+	}
+}
+
+func TestRouteLookup_WildcardRoute(t *testing.T) {
+	root := newTestRouteTree(t, "/files/*rest")
+
+	node, params := root.lookup("/files/a/b/c.txt", http.MethodGet)
+	if node == nil || node.pattern != "/files/*rest" {
+		t.Fatalf("Expected /files/*rest, got %v", node)
+	}
+	if value, _ := params.get("rest"); value != "a/b/c.txt" {
+		t.Errorf("Expected rest = a/b/c.txt, got %s", value)
+	}
+}
+
+func TestRouteLookup_Precedence(t *testing.T) {
+	root := newTestRouteTree(t, "/items/new", "/items/{id}", "/items/*rest", "/items/{id}/detail")
+
+	testCases := map[string]string{
+		"/items/new":        "/items/new",
+		"/items/1":          "/items/{id}",
+		"/items/1/detail":   "/items/{id}/detail",
+		"/items/new/detail": "/items/{id}/detail",
+		"/items/1/other":    "/items/*rest",
+	}
+
+	for path, pattern := range testCases {
+		node, _ := root.lookup(path, http.MethodGet)
+		if node == nil || node.pattern != pattern {
+			t.Errorf("Path %s: expected %s, got %v", path, pattern, node)
+		}
+	}
+}
+
+func TestRouteLookup_NotFound(t *testing.T) {
+	root := newTestRouteTree(t, "/items/{id}")
+
+	if node, _ := root.lookup("/items", http.MethodGet); node != nil {
+		t.Errorf("Expected nil, got %s", node.pattern)
+	}
+	if node, _ := root.lookup("/items/", http.MethodGet); node != nil {
+		t.Errorf("Expected nil, got %s", node.pattern)
+	}
+}
+
+func TestAddRoute_Conflict(t *testing.T) {
+	root := newTestRouteTree(t, "/items/{id}", "/files/*rest")
+
+	if err := root.addRoute(Route{URL: "/items/{id}", Method: http.MethodGet}); err != ERROR_ROUTE_CONFLICT {
+		t.Errorf("Expected ERROR_ROUTE_CONFLICT, got %v", err)
+	}
+	if err := root.addRoute(Route{URL: "/items/{name}", Method: http.MethodPost}); err != ERROR_ROUTE_CONFLICT {
+		t.Errorf("Expected ERROR_ROUTE_CONFLICT, got %v", err)
+	}
+	if err := root.addRoute(Route{URL: "/files/*path", Method: http.MethodPost}); err != ERROR_ROUTE_CONFLICT {
+		t.Errorf("Expected ERROR_ROUTE_CONFLICT, got %v", err)
+	}
+	if err := root.addRoute(Route{URL: "/items/{id}", Method: http.MethodPost}); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+}
+
+func TestAddRoute_InvalidPattern(t *testing.T) {
+	root := newTestRouteTree(t)
+
+	if err := root.addRoute(Route{URL: "/files/*rest/detail", Method: http.MethodGet}); err != ERROR_ROUTE_PATTERN_INVALID {
+		t.Errorf("Expected ERROR_ROUTE_PATTERN_INVALID, got %v", err)
+	}
+	if err := root.addRoute(Route{URL: "items", Method: http.MethodGet}); err != ERROR_ROUTE_PATTERN_INVALID {
+		t.Errorf("Expected ERROR_ROUTE_PATTERN_INVALID, got %v", err)
+	}
+}
+
+func TestRouteNode_AllowMethods(t *testing.T) {
+	root := newRouteNode(BLANK, segmentKind_Static)
+	root.addRoute(Route{URL: "/items", Method: http.MethodGet})
+	root.addRoute(Route{URL: "/items", Method: http.MethodPost})
+
+	node, _ := root.lookup("/items", http.MethodGet)
+	if node.allow != "GET, POST, HEAD, OPTIONS" {
+		t.Errorf("Expected GET, POST, HEAD, OPTIONS, got %s", node.allow)
+	}
+
+	if route := node.findRoute(http.MethodHead); route == nil || route.Method != http.MethodGet {
+		t.Errorf("Expected HEAD is handled by GET route, got %v", route)
+	}
+	if route := node.findRoute(http.MethodDelete); route != nil {
+		t.Errorf("Expected nil, got %v", route)
+	}
+}
+
+func TestRouteLookup_MethodFallback(t *testing.T) {
+	root := newRouteNode(BLANK, segmentKind_Static)
+	root.addRoute(Route{URL: "/items/new", Method: http.MethodPost})
+	root.addRoute(Route{URL: "/items/{id}", Method: http.MethodGet})
+	root.addRoute(Route{URL: "/files/list", Method: http.MethodPost})
+	root.addRoute(Route{URL: "/files/*rest", Method: http.MethodGet})
+
+	testCases := []struct {
+		method  string
+		path    string
+		pattern string
+	}{
+		{method: http.MethodGet, path: "/items/new", pattern: "/items/{id}"},
+		{method: http.MethodHead, path: "/items/new", pattern: "/items/{id}"},
+		{method: http.MethodPost, path: "/items/new", pattern: "/items/new"},
+		{method: http.MethodGet, path: "/files/list", pattern: "/files/*rest"},
+		// No node has route of method, static node is used to answer 405
+		{method: http.MethodDelete, path: "/items/new", pattern: "/items/new"},
+	}
+	for _, testCase := range testCases {
+		node, _ := root.lookup(testCase.path, testCase.method)
+		if node == nil || node.pattern != testCase.pattern {
+			t.Errorf("%s %s: expected %s, got %v", testCase.method, testCase.path, testCase.pattern, node)
+		}
+	}
+}
+
+func TestServeHTTP_MethodNotAllowed(t *testing.T) {
+	useTestRouter(t)
+	RegisterAPI("/items", http.MethodGet, corsTestHandler)
+
+	recorder := serveTestRequest(http.MethodDelete, "/items", nil)
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", recorder.Code)
+	}
+	if allow := recorder.Header().Get("Allow"); allow != "GET, HEAD, OPTIONS" {
+		t.Errorf("Expected Allow: GET, HEAD, OPTIONS, got %s", allow)
+	}
+
+	if recorder := serveTestRequest(http.MethodGet, "/other", nil); recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", recorder.Code)
+	}
+}
+
+func TestServeHTTP_HeadWithoutBody(t *testing.T) {
+	useTestRouter(t)
+	RegisterAPI("/items", http.MethodGet, corsTestHandler)
+
+	recorder := serveTestRequest(http.MethodHead, "/items", nil)
+	if recorder.Code != http.StatusOK || recorder.Body.Len() != 0 {
+		t.Errorf("Expected 200 without body, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if contentType := recorder.Header().Get(CONTENT_TYPE_KEY); contentType != JSON_CONTENT_TYPE {
+		t.Errorf("Expected content type of GET, got %s", contentType)
+	}
+}
+
+func TestServeHTTP_AutomaticOptions(t *testing.T) {
+	useTestRouter(t)
+	UseCors(CorsConfig{AllowOrigins: []string{"https://app.example.com"}})
+	RegisterAPI("/items", http.MethodGet, corsTestHandler)
+	RegisterAPI("/items", http.MethodPost, corsTestHandler)
+
+	recorder := serveTestRequest(http.MethodOptions, "/items", nil)
+	if recorder.Code != http.StatusNoContent || recorder.Header().Get("Allow") != "GET, POST, HEAD, OPTIONS" {
+		t.Errorf("Expected 204 with Allow header, got %d %v", recorder.Code, recorder.Header())
+	}
+
+	recorder = serveTestRequest(http.MethodOptions, "/items", map[string]string{
+		"Origin":                        "https://app.example.com",
+		"Access-Control-Request-Method": http.MethodPost,
+	})
+	header := recorder.Header()
+	if recorder.Code != http.StatusNoContent || recorder.Body.Len() != 0 {
+		t.Errorf("Expected 204 without body, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if header.Get("Access-Control-Allow-Origin") != "https://app.example.com" || !strings.Contains(header.Get("Access-Control-Allow-Methods"), http.MethodPost) {
+		t.Errorf("Expected cors headers, got %v", header)
+	}
+}
+
+func TestServeHTTP_AllowOfAllMatchedRoutes(t *testing.T) {
+	useTestRouter(t)
+	RegisterAPI("/items/new", http.MethodPost, corsTestHandler)
+	RegisterAPI("/items/{id}", http.MethodGet, corsTestHandler)
+
+	recorder := serveTestRequest(http.MethodDelete, "/items/new", nil)
+	if recorder.Code != http.StatusMethodNotAllowed || recorder.Header().Get("Allow") != "POST, GET, HEAD, OPTIONS" {
+		t.Errorf("Expected 405 with Allow: POST, GET, HEAD, OPTIONS, got %d %s", recorder.Code, recorder.Header().Get("Allow"))
+	}
+
+	recorder = serveTestRequest(http.MethodOptions, "/items/new", nil)
+	if recorder.Code != http.StatusNoContent || recorder.Header().Get("Allow") != "POST, GET, HEAD, OPTIONS" {
+		t.Errorf("Expected 204 with Allow: POST, GET, HEAD, OPTIONS, got %d %s", recorder.Code, recorder.Header().Get("Allow"))
+	}
+
+	if recorder := serveTestRequest(http.MethodGet, "/items/new", nil); recorder.Code != http.StatusOK {
+		t.Errorf("Expected GET /items/new is handled by /items/{id}, got %d", recorder.Code)
+	}
+}
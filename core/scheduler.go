@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"time"
 )
 
@@ -23,8 +24,20 @@ func startScheduler(interval time.Duration) {
 	w.Start(0, interval)
 }
 
-func stopScheduler() {
-	done <- true
+/*
+* stopScheduler: stop worker, it waits for execution in progress of worker until ctx is done
+* @params: ctx context.Context
+ */
+func stopScheduler(ctx context.Context) {
+	if w == nil {
+		return
+	}
+	w = nil
+	select {
+	case done <- true:
+	case <-ctx.Done():
+		LoggerInstance.Warning("Shutdown: worker is not stopped before timeout")
+	}
 }
 
 func GetBucket(time time.Time) int64 {
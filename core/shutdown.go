@@ -0,0 +1,191 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+const DEFAULT_SHUTDOWN_TIMEOUT = 30 // Seconds
+
+/*
+* shutdownContext: it is canceled when server starts shutting down
+* Long-lived connections (server-sent events, websocket) are closed by it, they are not waited by http server
+ */
+var shutdownContext, cancelShutdown = context.WithCancel(context.Background())
+
+var servers []*http.Server
+
+/*
+* consumer: a running consumer of message queue, it is canceled when server shuts down
+ */
+type consumer struct {
+	name   string
+	cancel func() error
+}
+
+var consumerMutex sync.Mutex
+var consumers []consumer
+var consumerWait sync.WaitGroup
+
+/*
+* Run: Start server and wait for SIGINT, SIGTERM, then shut down gracefully
+* Order of shutdown: stop accepting connections, wait for in-flight requests,
+* stop consumers, stop worker, release database, redis and rabbitmq
+* Time to wait is server.shutdown_timeout in config
+* @return void
+ */
+func Run() {
+	serverError := serve()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case sig := <-signals:
+		LoggerInstance.Info("Receive signal %s, shut down server", sig.String())
+	case err := <-serverError:
+		LoggerInstance.Error("Server is stopped: %s", err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), Config.Server.GetShutdownTimeout())
+	defer cancel()
+	shutdown(ctx)
+	Release()
+}
+
+/*
+* Start: Start server
+* Register all routes and listen to listeners in config
+* It blocks until server fails, use Run to shut down server gracefully
+* @return void
+ */
+func Start() {
+	if err := <-serve(); err != nil {
+		log.Fatalln("Serve fail: ", err)
+	}
+}
+
+/*
+* serve: register all routes and start a server for each listener in config
+* @return <-chan error: error of servers
+ */
+func serve() <-chan error {
+	// Serve api document
+	if Config.OpenAPI.Enable {
+		registerOpenAPIRoute()
+	}
+
+	// Serve prometheus metrics
+	if Config.Metrics.Enable {
+		registerMetricsRoute()
+	}
+
+	// Serve health and readiness
+	if Config.Health.Enable {
+		registerHealthRoutes()
+	}
+
+	// Register all routes
+	http.HandleFunc("/", serveHTTP)
+
+	// Listen and serve
+	listeners := Config.Server.GetListeners()
+	serverError := make(chan error, len(listeners))
+	servers = make([]*http.Server, 0, len(listeners))
+	for _, listenerConfig := range listeners {
+		name := listenerName(listenerConfig)
+		server, listener, err := newServer(listenerConfig)
+		if err != nil {
+			LoggerInstance.Error("Listen %s fail: %s", name, err.Error())
+			serverError <- err
+			continue
+		}
+		servers = append(servers, server)
+
+		go func(name string, config ListenerConfig, server *http.Server, listener net.Listener) {
+			LoggerInstance.Info("Start server at: %s (tls: %t)", name, config.IsTLS())
+			var err error
+			if config.IsTLS() {
+				err = server.ServeTLS(listener, BLANK, BLANK)
+			} else {
+				err = server.Serve(listener)
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				LoggerInstance.Error("Serve %s fail: %s", name, err.Error())
+				serverError <- err
+			}
+		}(name, listenerConfig, server, listener)
+	}
+	return serverError
+}
+
+/*
+* shutdown: stop servers, consumers and worker, it waits for them until ctx is done
+ */
+func shutdown(ctx context.Context) {
+	LoggerInstance.Info("Shutdown: stop accepting connections and wait for in-flight requests")
+	isReady.Store(false)
+	cancelShutdown()
+	wait := sync.WaitGroup{}
+	for _, server := range servers {
+		wait.Add(1)
+		go func(server *http.Server) {
+			defer wait.Done()
+			if err := server.Shutdown(ctx); err != nil {
+				LoggerInstance.Error("Shutdown server %s fail: %s", server.Addr, err.Error())
+			}
+		}(server)
+	}
+	wait.Wait()
+
+	LoggerInstance.Info("Shutdown: stop consumers")
+	stopConsumers(ctx)
+
+	LoggerInstance.Info("Shutdown: stop worker")
+	stopScheduler(ctx)
+}
+
+/*
+* registerConsumer: add a consumer that is canceled when server shuts down
+* Goroutine of consumer must call consumerWait.Done() when it stops
+ */
+func registerConsumer(name string, cancel func() error) {
+	consumerMutex.Lock()
+	defer consumerMutex.Unlock()
+	consumers = append(consumers, consumer{name: name, cancel: cancel})
+	consumerWait.Add(1)
+}
+
+/*
+* stopConsumers: cancel all consumers and wait for messages in progress until ctx is done
+ */
+func stopConsumers(ctx context.Context) {
+	consumerMutex.Lock()
+	for _, consumer := range consumers {
+		if err := consumer.cancel(); err != nil {
+			LoggerInstance.Error("Cancel consumer %s fail: %s", consumer.name, err.Error())
+		}
+	}
+	consumers = nil
+	consumerMutex.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		consumerWait.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		LoggerInstance.Warning("Shutdown: consumers are not stopped before timeout")
+	}
+}
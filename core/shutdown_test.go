@@ -0,0 +1,56 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStopConsumers_WaitForRunningConsumer(t *testing.T) {
+	messages := make(chan int, 1)
+	messages <- 1
+	handled := 0
+	registerConsumer("test", func() error {
+		close(messages)
+		return nil
+	})
+	go func() {
+		defer consumerWait.Done()
+		for range messages {
+			time.Sleep(10 * time.Millisecond)
+			handled++
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stopConsumers(ctx)
+
+	if handled != 1 {
+		t.Errorf("Expected 1 handled message, got %d", handled)
+	}
+	if len(consumers) != 0 {
+		t.Errorf("Expected no consumer, got %d", len(consumers))
+	}
+}
+
+func TestStopScheduler_NotStarted(t *testing.T) {
+	w = nil
+	stopScheduler(context.Background())
+}
+
+func TestStopScheduler_BusyWorkerTimeout(t *testing.T) {
+	// Worker is busy in execute, it doesn't receive from done
+	w = &worker{}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	stopScheduler(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected stop scheduler returns at timeout, got %s", elapsed)
+	}
+	if w != nil {
+		t.Errorf("Expected worker is removed")
+	}
+}
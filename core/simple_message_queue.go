@@ -1,6 +1,7 @@
 package core
 
 import (
+	"fmt"
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
@@ -84,18 +85,40 @@ func (mqs *SimpleMessageQueueSession) recreateSession() bool {
 	return true
 }
 
+/*
+* Publish: publish message with core context, request id and span of caller are not sent to consumer
+*
+* Deprecated: use PublishWithContext with context of request, it is required to propagate request id to consumer
+* @params: body []byte
+* @return: Error
+ */
 func (mqs *SimpleMessageQueueSession) Publish(body []byte) Error {
+	return mqs.PublishWithContext(coreContext, body)
+}
+
+/*
+* PublishWithContext: publish message with request id of context in headers
+* Consumer of message gets the request id, so logs can be joined across services
+* @params: ctx *Context
+* @params: body []byte
+* @return: Error
+ */
+func (mqs *SimpleMessageQueueSession) PublishWithContext(ctx *Context, body []byte) Error {
+	headers, span := startPublishSpan(ctx, mqs.config.QueueName, ctx.messageHeaders())
 	err := mqs.channel.PublishWithContext(
-		coreContext,
+		ctx,
 		mqs.config.ExchangeName,
 		mqs.config.QueueName,
 		false,
 		false,
 		amqp.Publishing{
 			ContentType: CONTENT_TYPE_TEXT,
+			Headers:     headers,
 			Body:        body,
 		},
 	)
+	endSpan(span, err)
+	observePublish(mqs.config.QueueName, err)
 
 	if err != nil {
 		LoggerInstance.Error("Publish message: %v", err)
@@ -106,17 +129,29 @@ func (mqs *SimpleMessageQueueSession) Publish(body []byte) Error {
 }
 
 func (mqs *SimpleMessageQueueSession) Consume(handler func(msg RabbitmqMessage)) Error {
-	messages, err := mqs.channel.Consume(mqs.config.QueueName, BLANK, true, mqs.config.Exclusive, false, mqs.config.NoWait, nil)
+	consumerTag := fmt.Sprintf("%s_%s", mqs.config.QueueName, ID.GenerateID())
+	messages, err := mqs.channel.Consume(mqs.config.QueueName, consumerTag, true, mqs.config.Exclusive, false, mqs.config.NoWait, nil)
 	if err != nil {
 		LoggerInstance.Error("Error when consume messages: %s", err.Error())
 		return ERROR_CANNOT_CONSUME_MESSAGES_FROM_RABBITMQ
 	}
 
+	registerConsumer(mqs.config.QueueName, func() error {
+		return mqs.channel.Cancel(consumerTag, false)
+	})
+
 	go func(messages <-chan amqp.Delivery) {
+		defer consumerWait.Done()
 		for message := range messages {
+			start := time.Now()
+			ctx := newConsumeContext(mqs.config.QueueName, message.Headers)
 			handler(RabbitmqMessage{
-				Body: message.Body,
+				Body:      message.Body,
+				RequestID: requestIDFromMessage(message.Headers),
+				Context:   ctx,
 			})
+			PutContext(ctx)
+			observeConsume(mqs.config.QueueName, start)
 		}
 	}(messages)
 
@@ -0,0 +1,272 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	EVENT_STREAM_CONTENT_TYPE     = "text/event-stream"
+	LAST_EVENT_ID_KEY             = "Last-Event-ID"
+	DEFAULT_SSE_HEARTBEAT_SECONDS = 15
+)
+
+var errEventStreamClosed = errors.New("event stream is closed")
+var errInvalidEventField = errors.New("event and id of event must not contain new line")
+
+/*
+* SSEHandler: handler of server-sent events api
+* Handler sends events by stream until it returns or client disconnects (ctx.Done() is closed)
+* Error is written as response only if no event is sent before
+ */
+type SSEHandler func(ctx *Context, stream *EventStream) HttpError
+
+/*
+* EventStream: writer of server-sent events
+ */
+type EventStream struct {
+	ctx        *Context
+	controller *http.ResponseController
+	mutex      sync.Mutex
+	isStarted  bool
+	isClosed   bool
+	// Last-Event-ID header of client, it isn't changed by sent events
+	lastEventID string
+	sentEventID string
+	done        chan struct{}
+	wait        sync.WaitGroup
+}
+
+/*
+* RegisterSSE: register server-sent events api with method GET
+* Context of handler is not limited by context timeout, it is canceled when client disconnects
+* @params: url string
+* @params: handler SSEHandler
+* @params: middlewares ...Middleware
+* @return: void
+ */
+func RegisterSSE(url string, handler SSEHandler, middlewares ...Middleware) {
+	LoggerInstance.Info("Register sse: %s", url)
+	h := func(writer http.ResponseWriter, request *http.Request, params pathParams) {
+		ctx := getContext()
+		defer putContext(ctx)
+		defer ctx.recoverPanic()
+		buildContext(ctx, writer, request, params)
+		ctx.instrumentRequest(url)
+
+		middlewareList := []Middleware{}
+		middlewareList = append(middlewareList, commonMiddlewares...)
+		middlewareList = append(middlewareList, middlewares...)
+		if ctx.runMiddlewares(middlewareList) {
+			return
+		}
+
+		// Events are flushed through ctx.rw, so writers of middlewares (compress, ...) flush their buffer
+		if !canFlush(writer) {
+			ctx.LogError("Response writer does not support flush")
+			ctx.writeError(HTTP_ERROR_STREAMING_NOT_SUPPORTED)
+			return
+		}
+
+		// Stream lives until client disconnects or server shuts down
+		ctx.useStreamContext(request)
+		stop := context.AfterFunc(shutdownContext, ctx.cancelFunc)
+		defer stop()
+
+		stream := &EventStream{
+			ctx:         ctx,
+			controller:  http.NewResponseController(ctx.rw),
+			lastEventID: request.Header.Get(LAST_EVENT_ID_KEY),
+			done:        make(chan struct{}),
+		}
+		err := handler(ctx, stream)
+		stream.close()
+
+		if err != nil {
+			if stream.isStarted {
+				ctx.LogError("Event stream is closed with error: %s", err.Error())
+				return
+			}
+			ctx.writeError(err)
+		}
+	}
+
+	registerRoute(url, http.MethodGet, h, nil)
+}
+
+/*
+* LastEventID: id of last event that client received, it is sent by Last-Event-ID header when client reconnects
+* Handler uses it to resume events from that id
+* @return: string (blank on first connection)
+ */
+func (stream *EventStream) LastEventID() string {
+	return stream.lastEventID
+}
+
+/*
+* SentEventID: id of last event that is sent by this stream
+* @return: string (blank if no event with id is sent)
+ */
+func (stream *EventStream) SentEventID() string {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	return stream.sentEventID
+}
+
+/*
+* Send: send an event to client
+* @params: event string (blank is "message" event)
+* @params: id string (blank is no id)
+* @params: data any (string, []byte are sent as they are, other types are encoded to json)
+* @return: error (event or id contains new line, client disconnected or write fail)
+ */
+func (stream *EventStream) Send(event string, id string, data any) error {
+	// New line in event or id would start another field of event
+	if strings.ContainsAny(event, "\r\n") || strings.ContainsAny(id, "\r\n") {
+		return errInvalidEventField
+	}
+
+	var payload string
+	switch value := data.(type) {
+	case string:
+		payload = value
+	case []byte:
+		payload = string(value)
+	default:
+		body, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		payload = string(body)
+	}
+
+	builder := strings.Builder{}
+	if id != BLANK {
+		fmt.Fprintf(&builder, "id: %s\n", id)
+	}
+	if event != BLANK {
+		fmt.Fprintf(&builder, "event: %s\n", event)
+	}
+	// CRLF and CR are also line terminators of event stream, each line of payload is a data field
+	payload = strings.ReplaceAll(strings.ReplaceAll(payload, "\r\n", "\n"), "\r", "\n")
+	for _, line := range strings.Split(payload, "\n") {
+		fmt.Fprintf(&builder, "data: %s\n", line)
+	}
+	builder.WriteString("\n")
+
+	return stream.write(builder.String(), id)
+}
+
+/*
+* Comment: send a comment line, client ignores it
+* @params: text string
+* @return: error
+ */
+func (stream *EventStream) Comment(text string) error {
+	return stream.write(fmt.Sprintf(": %s\n\n", text), BLANK)
+}
+
+/*
+* write: write data to connection and flush it
+* Headers and heartbeat are started at first write
+* @params: data string, id string (id of event, blank if data has no id)
+ */
+func (stream *EventStream) write(data string, id string) error {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	if stream.isClosed {
+		return errEventStreamClosed
+	}
+	if err := stream.ctx.Err(); err != nil {
+		return err
+	}
+
+	if !stream.isStarted {
+		stream.start()
+	}
+	if _, err := stream.ctx.rw.Write([]byte(data)); err != nil {
+		return err
+	}
+	if err := stream.controller.Flush(); err != nil {
+		return err
+	}
+	if id != BLANK {
+		stream.sentEventID = id
+	}
+	return nil
+}
+
+/*
+* canFlush: check writer or writers that it wraps can flush, like http.ResponseController
+ */
+func canFlush(writer http.ResponseWriter) bool {
+	for {
+		switch value := writer.(type) {
+		case http.Flusher:
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			writer = value.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+/*
+* start: write headers of event stream and start heartbeat
+ */
+func (stream *EventStream) start() {
+	stream.isStarted = true
+	stream.ctx.isResponseEnd = true
+
+	header := stream.ctx.rw.Header()
+	header.Set(CONTENT_TYPE_KEY, EVENT_STREAM_CONTENT_TYPE)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	stream.ctx.rw.WriteHeader(http.StatusOK)
+
+	stream.wait.Add(1)
+	go stream.heartbeat(Config.SSE.GetHeartbeatInterval())
+}
+
+/*
+* heartbeat: send comment periodically, so proxies don't close idle connection
+ */
+func (stream *EventStream) heartbeat(interval time.Duration) {
+	defer stream.wait.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stream.done:
+			return
+		case <-stream.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := stream.Comment("heartbeat"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+/*
+* close: stop heartbeat, nothing is written to connection after it
+ */
+func (stream *EventStream) close() {
+	stream.mutex.Lock()
+	stream.isClosed = true
+	stream.mutex.Unlock()
+
+	close(stream.done)
+	stream.wait.Wait()
+}
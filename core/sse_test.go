@@ -0,0 +1,127 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestEventStream(recorder *httptest.ResponseRecorder) *EventStream {
+	ctx := &Context{Context: context.Background(), rw: recorder}
+	return &EventStream{ctx: ctx, controller: http.NewResponseController(recorder), done: make(chan struct{})}
+}
+
+func TestEventStream_Send(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	stream := newTestEventStream(recorder)
+
+	if err := stream.Send("update", "1", map[string]string{"name": "test"}); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	if err := stream.Send(BLANK, BLANK, "line 1\nline 2"); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	stream.close()
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", recorder.Code)
+	}
+	if contentType := recorder.Header().Get(CONTENT_TYPE_KEY); contentType != EVENT_STREAM_CONTENT_TYPE {
+		t.Errorf("Expected %s, got %s", EVENT_STREAM_CONTENT_TYPE, contentType)
+	}
+	expected := "id: 1\nevent: update\ndata: {\"name\":\"test\"}\n\ndata: line 1\ndata: line 2\n\n"
+	if body := recorder.Body.String(); body != expected {
+		t.Errorf("Expected %q, got %q", expected, body)
+	}
+	if stream.SentEventID() != "1" || stream.LastEventID() != BLANK {
+		t.Errorf("Expected sent event id 1 and blank last event id, got %s %s", stream.SentEventID(), stream.LastEventID())
+	}
+}
+
+func TestEventStream_SendAfterClose(t *testing.T) {
+	stream := newTestEventStream(httptest.NewRecorder())
+	stream.close()
+
+	if err := stream.Send(BLANK, BLANK, "test"); err != errEventStreamClosed {
+		t.Errorf("Expected errEventStreamClosed, got %v", err)
+	}
+}
+
+func TestEventStream_SendInvalidField(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	stream := newTestEventStream(recorder)
+	defer stream.close()
+
+	if err := stream.Send("update\ndata: fake", BLANK, "test"); err != errInvalidEventField {
+		t.Errorf("Expected errInvalidEventField for event, got %v", err)
+	}
+	if err := stream.Send(BLANK, "1\r2", "test"); err != errInvalidEventField {
+		t.Errorf("Expected errInvalidEventField for id, got %v", err)
+	}
+	if recorder.Body.Len() != 0 {
+		t.Errorf("Expected nothing is written, got %q", recorder.Body.String())
+	}
+}
+
+func TestEventStream_SendDataWithCarriageReturn(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	stream := newTestEventStream(recorder)
+
+	if err := stream.Send(BLANK, BLANK, "hello\revent: admin\rid: 999\r\nend"); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	stream.close()
+
+	expected := "data: hello\ndata: event: admin\ndata: id: 999\ndata: end\n\n"
+	if body := recorder.Body.String(); body != expected {
+		t.Errorf("Expected %q, got %q", expected, body)
+	}
+}
+
+func TestRegisterSSE_Stream(t *testing.T) {
+	useTestRouter(t)
+	oldSSEConfig := Config.SSE
+	Config.SSE.HeartbeatInterval = 1
+	t.Cleanup(func() { Config.SSE = oldSSEConfig })
+
+	userKey := NewContextKey[string]("user")
+	authorize := func(ctx *Context) HttpError {
+		if ctx.GetRequestHeader("Authorization") == BLANK {
+			return HTTP_ERROR_UNAUTHORIZED
+		}
+		userKey.Set(ctx, "alice")
+		ctx.Next()
+		return nil
+	}
+	RegisterSSE("/events", func(ctx *Context, stream *EventStream) HttpError {
+		user, _ := userKey.Get(ctx)
+		if err := stream.Send("resume", stream.LastEventID(), user); err != nil {
+			return NewHttpError(http.StatusInternalServerError, ERROR_CODE_INTERNAL_SERVER_ERROR, err.Error(), nil)
+		}
+		if err := stream.Send("update", "6", "next"); err != nil {
+			return NewHttpError(http.StatusInternalServerError, ERROR_CODE_INTERNAL_SERVER_ERROR, err.Error(), nil)
+		}
+		time.Sleep(1500 * time.Millisecond)
+		if stream.LastEventID() != "5" || stream.SentEventID() != "6" {
+			t.Errorf("Expected last event id 5 and sent event id 6, got %s %s", stream.LastEventID(), stream.SentEventID())
+		}
+		return nil
+	}, authorize)
+
+	recorder := serveTestRequest(http.MethodGet, "/events", nil)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 from middleware, got %d", recorder.Code)
+	}
+
+	recorder = serveTestRequest(http.MethodGet, "/events", map[string]string{"Authorization": "token", LAST_EVENT_ID_KEY: "5"})
+	if recorder.Code != http.StatusOK || recorder.Header().Get(CONTENT_TYPE_KEY) != EVENT_STREAM_CONTENT_TYPE {
+		t.Fatalf("Expected event stream, got %d %v", recorder.Code, recorder.Header())
+	}
+	expected := "id: 5\nevent: resume\ndata: alice\n\nid: 6\nevent: update\ndata: next\n\n: heartbeat\n\n"
+	if body := recorder.Body.String(); !strings.HasPrefix(body, expected) {
+		t.Errorf("Expected %q, got %q", expected, body)
+	}
+}
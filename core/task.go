@@ -45,7 +45,7 @@ func StartTask(ctx *Context, request *StartTaskRequest) Error {
 	taskId := ID.GenerateID()
 
 	// Init transaction
-	tx, err := DBSession().BeginTx(ctx, &sql.TxOptions{})
+	tx, err := DBSession().BeginTxTraced(ctx, &sql.TxOptions{})
 	if err != nil {
 		ctx.LogError("Begin transaction fail: %v, err = %s", *request, err.Error())
 		return ERROR_ADD_TASK_SYSTEM_FAIL
@@ -108,7 +108,7 @@ type StopTaskRequest struct {
 }
 
 func StopTask(ctx *Context, request *StopTaskRequest) Error {
-	tx, err := DBSession().BeginTx(ctx, &sql.TxOptions{})
+	tx, err := DBSession().BeginTxTraced(ctx, &sql.TxOptions{})
 	if err != nil {
 		ctx.LogError("Begin transaction fail: %v, error = %s", *request, err.Error())
 		return ERROR_STOP_TASK_FAIL
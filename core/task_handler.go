@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
@@ -36,20 +37,29 @@ func HandleTask(taskQueueName string, handler TaskHandler) Error {
 		return err
 	}
 
-	messages, errConsume := session.channel.Consume(session.config.QueueName, BLANK, true, session.config.Exclusive, false, session.config.NoWait, nil)
+	consumerTag := fmt.Sprintf("%s_%s", session.config.QueueName, ID.GenerateID())
+	messages, errConsume := session.channel.Consume(session.config.QueueName, consumerTag, true, session.config.Exclusive, false, session.config.NoWait, nil)
 	if errConsume != nil {
-		LoggerInstance.Error("Error when handle task: %s", err.Error())
+		LoggerInstance.Error("Error when handle task: %s", errConsume.Error())
 		return ERROR_CANNOT_CONSUME_MESSAGES_FROM_RABBITMQ
 	}
 
+	registerConsumer(session.config.QueueName, func() error {
+		return session.channel.Cancel(consumerTag, false)
+	})
+
 	go func(messages <-chan amqp.Delivery) {
+		defer consumerWait.Done()
 		for message := range messages {
-			ctx := GetContextWithTimeout(Config.GetTaskTimeout())
+			start := time.Now()
+			ctx := newConsumeContext(queueConfig.QueueName, message.Headers)
 			ctx.LogInfo("Start handle task: %s", queueConfig.QueueName)
 			handler(ctx, TaskInfo{
 				Data: message.Body,
 			})
 			ctx.LogInfo("End handle task: %s", queueConfig.QueueName)
+			PutContext(ctx)
+			observeConsume(queueConfig.QueueName, start)
 		}
 	}(messages)
 
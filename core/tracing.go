@@ -0,0 +1,305 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	TRACER_NAME           = "core"
+	DEFAULT_OTLP_ENDPOINT = "localhost:4318"
+)
+
+var tracerProvider *sdktrace.TracerProvider
+
+/*
+* initTracing: export spans to otlp collector by http
+* Spans are not created by global noop tracer when tracing is disabled
+ */
+func initTracing() {
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(Config.Tracing.GetEndpoint())}
+	if Config.Tracing.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+	if err != nil {
+		LoggerInstance.Error("Create otlp exporter fail: %s", err.Error())
+		return
+	}
+
+	res := resource.NewSchemaless(attribute.String("service.name", Config.Tracing.GetServiceName()))
+	sampler := sdktrace.ParentBased(sdktrace.TraceIDRatioBased(Config.Tracing.GetSampleRatio()))
+	setTracerProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sampler),
+	))
+	LoggerInstance.Info("Export traces to: %s", Config.Tracing.GetEndpoint())
+}
+
+/*
+* setTracerProvider: use tracer provider and W3C trace context propagator
+ */
+func setTracerProvider(provider *sdktrace.TracerProvider) {
+	tracerProvider = provider
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+/*
+* shutdownTracing: flush spans that are not exported
+ */
+func shutdownTracing() {
+	if tracerProvider == nil {
+		return
+	}
+	if err := tracerProvider.Shutdown(context.Background()); err != nil {
+		LoggerInstance.Error("Shutdown tracer provider fail: %s", err.Error())
+	}
+	tracerProvider = nil
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(TRACER_NAME)
+}
+
+/*
+* startServerSpan: start span of http request, parent span is extracted from request headers
+* Span is ended with status code of response when request is done
+* @params: route string (url pattern of api)
+ */
+func (ctx *Context) startServerSpan(route string) {
+	request := ctx.request
+	parent := otel.GetTextMapPropagator().Extract(ctx.Context, propagation.HeaderCarrier(request.Header))
+	spanContext, span := tracer().Start(parent, fmt.Sprintf("%s %s", request.Method, route),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", request.Method),
+			attribute.String("http.route", route),
+			attribute.String("url.path", request.URL.Path),
+			attribute.String("request.id", ctx.requestID),
+		),
+	)
+	ctx.Context = spanContext
+
+	// Request id is trace id when it is propagated by traceparent
+	if isTraceparent() && span.SpanContext().HasTraceID() {
+		ctx.requestID = span.SpanContext().TraceID().String()
+	}
+	if !span.IsRecording() {
+		return
+	}
+
+	ctx.OnFinish(func(ctx *Context) {
+		status := ctx.GetResponseStatus()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		span.End()
+	})
+}
+
+/*
+* useStreamContext: context of stream (sse, websocket) isn't limited by context timeout, it is canceled when client disconnects
+* Server span of request is kept, so spans of handler are its children
+* @params: request *http.Request
+ */
+func (ctx *Context) useStreamContext(request *http.Request) {
+	span := trace.SpanFromContext(ctx.Context)
+	ctx.cancelFunc()
+	ctx.Context, ctx.cancelFunc = context.WithCancel(trace.ContextWithSpan(request.Context(), span))
+	ctx.Timeout = 0
+}
+
+/*
+* startClientSpan: start span of outgoing http request and inject it to request headers
+ */
+func startClientSpan(ctx context.Context, request *http.Request) trace.Span {
+	spanContext, span := tracer().Start(ctx, fmt.Sprintf("HTTP %s", request.Method),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", request.Method),
+			attribute.String("server.address", request.URL.Host),
+			attribute.String("url.full", request.URL.String()),
+		),
+	)
+	otel.GetTextMapPropagator().Inject(spanContext, propagation.HeaderCarrier(request.Header))
+	return span
+}
+
+/*
+* endClientSpan: end span of outgoing http request
+ */
+func endClientSpan(span trace.Span, response *http.Response, err error) {
+	if err != nil {
+		endSpan(span, err)
+		return
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", response.StatusCode))
+	if response.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(response.StatusCode))
+	}
+	span.End()
+}
+
+/*
+* endSpan: record error of span and end it
+ */
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+/*
+* startDBSpan: start span of database query
+ */
+func startDBSpan(ctx context.Context, operation string, query string) (context.Context, trace.Span) {
+	return tracer().Start(ctx, fmt.Sprintf("db %s", operation),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "sqlite"),
+			attribute.String("db.statement", query),
+		),
+	)
+}
+
+func (session dbSession) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return execWithSpan(ctx, session.DB.ExecContext, query, args...)
+}
+
+/*
+* QueryContext: span of query ends when query is executed, reading rows is not in span
+ */
+func (session dbSession) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return queryWithSpan(ctx, session.DB.QueryContext, query, args...)
+}
+
+func (session dbSession) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return queryRowWithSpan(ctx, session.DB.QueryRowContext, query, args...)
+}
+
+/*
+* BeginTxTraced: start transaction that traces its queries like queries of session
+* BeginTx returns *sql.Tx, its queries are not traced
+* @params: ctx context.Context, options *sql.TxOptions
+* @return: *TracedTx, error
+ */
+func (session dbSession) BeginTxTraced(ctx context.Context, options *sql.TxOptions) (*TracedTx, error) {
+	tx, err := session.DB.BeginTx(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return &TracedTx{Tx: tx}, nil
+}
+
+/*
+* TracedTx: transaction that creates span for each query, Commit and Rollback are of *sql.Tx
+ */
+type TracedTx struct {
+	*sql.Tx
+}
+
+func (tx *TracedTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return execWithSpan(ctx, tx.Tx.ExecContext, query, args...)
+}
+
+func (tx *TracedTx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return queryWithSpan(ctx, tx.Tx.QueryContext, query, args...)
+}
+
+func (tx *TracedTx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return queryRowWithSpan(ctx, tx.Tx.QueryRowContext, query, args...)
+}
+
+func execWithSpan(ctx context.Context, exec func(context.Context, string, ...any) (sql.Result, error), query string, args ...any) (sql.Result, error) {
+	spanContext, span := startDBSpan(ctx, "exec", query)
+	result, err := exec(spanContext, query, args...)
+	endSpan(span, err)
+	return result, err
+}
+
+func queryWithSpan(ctx context.Context, queryFunc func(context.Context, string, ...any) (*sql.Rows, error), query string, args ...any) (*sql.Rows, error) {
+	spanContext, span := startDBSpan(ctx, "query", query)
+	rows, err := queryFunc(spanContext, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+func queryRowWithSpan(ctx context.Context, queryRow func(context.Context, string, ...any) *sql.Row, query string, args ...any) *sql.Row {
+	spanContext, span := startDBSpan(ctx, "query", query)
+	row := queryRow(spanContext, query, args...)
+	endSpan(span, row.Err())
+	return row
+}
+
+/*
+* amqpHeaderCarrier: carry trace context in headers of rabbitmq message
+ */
+type amqpHeaderCarrier amqp.Table
+
+func (carrier amqpHeaderCarrier) Get(key string) string {
+	value, _ := carrier[key].(string)
+	return value
+}
+
+func (carrier amqpHeaderCarrier) Set(key string, value string) {
+	carrier[key] = value
+}
+
+func (carrier amqpHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(carrier))
+	for key := range carrier {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+/*
+* startPublishSpan: start span of publishing message and inject it to headers of message
+ */
+func startPublishSpan(ctx context.Context, destination string, headers amqp.Table) (amqp.Table, trace.Span) {
+	spanContext, span := tracer().Start(ctx, fmt.Sprintf("%s publish", destination),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.destination.name", destination),
+		),
+	)
+	if headers == nil {
+		headers = amqp.Table{}
+	}
+	otel.GetTextMapPropagator().Inject(spanContext, amqpHeaderCarrier(headers))
+	return headers, span
+}
+
+/*
+* startConsumeSpan: start span of handling message, parent span is extracted from headers of message
+ */
+func startConsumeSpan(ctx context.Context, destination string, headers amqp.Table) (context.Context, trace.Span) {
+	if headers != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, amqpHeaderCarrier(headers))
+	}
+	return tracer().Start(ctx, fmt.Sprintf("%s process", destination),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.destination.name", destination),
+		),
+	)
+}
@@ -0,0 +1,170 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func useTestTracer(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	setTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		tracerProvider = nil
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+	return recorder
+}
+
+type tracingTestResponse struct{}
+
+func TestTracing_ServerSpanPropagatesToClient(t *testing.T) {
+	recorder := useTestTracer(t)
+	if coreContext == nil {
+		coreContext = &Context{Context: context.Background()}
+	}
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		traceparent = request.Header.Get(TRACEPARENT_HEADER)
+		writer.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	request := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	request.Header.Set(TRACEPARENT_HEADER, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := &Context{Context: context.Background(), request: request, requestID: "1"}
+	ctx.response = responseWriter{ResponseWriter: httptest.NewRecorder()}
+	ctx.rw = &ctx.response
+
+	ctx.startServerSpan("/items/{id}")
+	if _, err := NewClient().SetContext(ctx).SetUrl(server.URL).SetMethod(http.MethodGet).Request(&tracingTestResponse{}); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	ctx.endResponse(http.StatusOK, "{}")
+	ctx.runFinishHooks()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	client, serverSpan := spans[0], spans[1]
+	if serverSpan.SpanKind() != trace.SpanKindServer || serverSpan.Name() != "GET /items/{id}" {
+		t.Errorf("Unexpected server span: %s %s", serverSpan.SpanKind(), serverSpan.Name())
+	}
+	if serverSpan.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected trace id from traceparent, got %s", serverSpan.SpanContext().TraceID())
+	}
+	if client.Parent().SpanID() != serverSpan.SpanContext().SpanID() {
+		t.Errorf("Expected client span is child of server span")
+	}
+	if !strings.Contains(traceparent, client.SpanContext().SpanID().String()) {
+		t.Errorf("Expected traceparent of client span, got %s", traceparent)
+	}
+}
+
+func TestTracing_ExportToCollector(t *testing.T) {
+	var exported atomic.Int32
+	collector := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/v1/traces" {
+			exported.Add(1)
+		}
+	}))
+	defer collector.Close()
+
+	Config.Tracing = TracingConfig{Endpoint: strings.TrimPrefix(collector.URL, "http://"), Insecure: true}
+	defer func() { Config.Tracing = TracingConfig{} }()
+	initTracing()
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	_, span := tracer().Start(context.Background(), "test")
+	span.End()
+	shutdownTracing()
+
+	if exported.Load() == 0 {
+		t.Errorf("Expected spans are exported to collector")
+	}
+}
+
+func TestTracing_DBTransaction(t *testing.T) {
+	recorder := useTestTracer(t)
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Open database fail: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	session := dbSession{db}
+	parent, span := tracer().Start(context.Background(), "parent")
+
+	if _, err := session.ExecContext(parent, "CREATE TABLE items(name TEXT)"); err != nil {
+		t.Fatalf("Create table fail: %v", err)
+	}
+	tx, err := session.BeginTxTraced(parent, &sql.TxOptions{})
+	if err != nil {
+		t.Fatalf("Begin transaction fail: %v", err)
+	}
+	if _, err := tx.ExecContext(parent, "INSERT INTO items(name) VALUES ($1), ($2)", "a", "b"); err != nil {
+		t.Fatalf("Insert fail: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit fail: %v", err)
+	}
+
+	// Types of database/sql are returned, so services that use them don't change
+	var rows *sql.Rows
+	rows, err = session.QueryContext(parent, "SELECT name FROM items")
+	if err != nil {
+		t.Fatalf("Query fail: %v", err)
+	}
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	rows.Close()
+	span.End()
+
+	spans := recorder.Ended()
+	if count != 2 || len(spans) != 4 {
+		t.Fatalf("Expected 2 rows and 4 spans, got %d %d", count, len(spans))
+	}
+	for _, dbSpan := range spans[:3] {
+		if dbSpan.Parent().SpanID() != span.SpanContext().SpanID() {
+			t.Errorf("Expected span %s is child of parent span", dbSpan.Name())
+		}
+	}
+	if spans[1].Name() != "db exec" || spans[2].Name() != "db query" {
+		t.Errorf("Expected exec span of transaction and query span, got %s %s", spans[1].Name(), spans[2].Name())
+	}
+}
+
+func TestTracing_StreamContextKeepsServerSpan(t *testing.T) {
+	useTestTracer(t)
+	request := httptest.NewRequest(http.MethodGet, "/events", nil)
+	ctx := &Context{Context: context.Background(), request: request, requestID: "1"}
+	ctx.Context, ctx.cancelFunc = context.WithTimeout(ctx.Context, time.Second)
+	ctx.startServerSpan("/events")
+	span := trace.SpanFromContext(ctx.Context)
+
+	ctx.useStreamContext(request)
+	defer ctx.cancelFunc()
+	if trace.SpanFromContext(ctx.Context).SpanContext().SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("Expected stream context has server span")
+	}
+	if _, ok := ctx.Deadline(); ok || ctx.Err() != nil {
+		t.Errorf("Expected stream context without timeout, got %v", ctx.Err())
+	}
+}
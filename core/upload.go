@@ -0,0 +1,121 @@
+package core
+
+import (
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+const (
+	MULTIPART_CONTENT_TYPE      = "multipart/form-data"
+	MULTIPART_MEDIA_TYPE_PREFIX = "multipart/"
+	DEFAULT_UPLOAD_MAX_MEMORY   = 32 << 20 // 32 MB
+)
+
+/*
+* UploadLimit: middleware set max size of multipart body of api
+* It overrides server.upload.max_size in config
+* @params: maxBytes int64
+* @return: Middleware
+ */
+func UploadLimit(maxBytes int64) Middleware {
+	return func(ctx *Context) HttpError {
+		ctx.uploadLimit = maxBytes
+		ctx.Next()
+		return nil
+	}
+}
+
+/*
+* isMultipart: check if request body is multipart
+ */
+func isMultipart(request *http.Request) bool {
+	return strings.HasPrefix(parseMediaType(request.Header.Get(CONTENT_TYPE_KEY)), MULTIPART_MEDIA_TYPE_PREFIX)
+}
+
+/*
+* limitUploadBody: limit size of multipart body by upload limit of api or config
+* Max body bytes of api or config is used when upload limit is not set, so multipart body is never unlimited by mistake
+ */
+func (ctx *Context) limitUploadBody() {
+	if ctx.isUploadLimited {
+		return
+	}
+	ctx.isUploadLimited = true
+
+	limit := Config.Server.Upload.MaxSize
+	if ctx.uploadLimit > 0 {
+		limit = ctx.uploadLimit
+	}
+	if limit <= 0 {
+		limit = Config.Server.MaxBodyBytes
+		if ctx.bodyLimit > 0 {
+			limit = ctx.bodyLimit
+		}
+	}
+	if limit > 0 {
+		ctx.request.Body = http.MaxBytesReader(ctx.rw, ctx.request.Body, limit)
+	}
+}
+
+/*
+* parseMultipartForm: parse multipart body, files are saved in memory up to max memory in config
+* the rest of files is saved to temporary files on disk, they are removed after request is done
+* @return: HttpError
+ */
+func (ctx *Context) parseMultipartForm() HttpError {
+	if ctx.request.MultipartForm != nil {
+		return nil
+	}
+
+	ctx.limitUploadBody()
+	if err := ctx.request.ParseMultipartForm(Config.Server.Upload.GetMaxMemory()); err != nil {
+		ctx.LogInfo("Parse multipart form fail: %s", err.Error())
+		return uploadError(err)
+	}
+	return nil
+}
+
+/*
+* MultipartReader: get reader to read parts of multipart body as a stream
+* Use it instead of FormFile when file is too large to save in memory or temporary file
+* It can't be used after FormFile, GetFormData or binding form, file tags
+* @return: *multipart.Reader, HttpError
+ */
+func (ctx *Context) MultipartReader() (*multipart.Reader, HttpError) {
+	ctx.limitUploadBody()
+	reader, err := ctx.request.MultipartReader()
+	if err != nil {
+		ctx.LogInfo("Get multipart reader fail: %s", err.Error())
+		return nil, HTTP_ERROR_PARSE_MULTIPART_FAIL
+	}
+	return reader, nil
+}
+
+/*
+* FormFile: get first file of a multipart form key
+* @params: name string
+* @return: multipart.File, *multipart.FileHeader, HttpError
+ */
+func (ctx *Context) FormFile(name string) (multipart.File, *multipart.FileHeader, HttpError) {
+	if err := ctx.parseMultipartForm(); err != nil {
+		return nil, nil, err
+	}
+
+	file, header, err := ctx.request.FormFile(name)
+	if err != nil {
+		ctx.LogInfo("Get form file %s fail: %s", name, err.Error())
+		return nil, nil, HTTP_ERROR_FORM_FILE_NOT_FOUND
+	}
+	return file, header, nil
+}
+
+/*
+* uploadError: convert error when read multipart body to http error
+ */
+func uploadError(err error) HttpError {
+	if isMaxBytesError(err) {
+		return HTTP_ERROR_REQUEST_ENTITY_TOO_LARGE
+	}
+	return HTTP_ERROR_PARSE_MULTIPART_FAIL
+}
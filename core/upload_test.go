@@ -0,0 +1,97 @@
+package core
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type uploadTestRequest struct {
+	Name   string                  `form:"name"`
+	Avatar *multipart.FileHeader   `file:"avatar"`
+	Files  []*multipart.FileHeader `file:"files"`
+}
+
+func newMultipartRequest(t *testing.T) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("name", "test")
+	for _, field := range []string{"avatar", "files", "files"} {
+		part, err := writer.CreateFormFile(field, field+".txt")
+		if err != nil {
+			t.Fatalf("Create form file fail: %s", err.Error())
+		}
+		part.Write([]byte("content of " + field))
+	}
+	writer.Close()
+
+	request := httptest.NewRequest(http.MethodPost, "/upload", body)
+	request.Header.Set(CONTENT_TYPE_KEY, writer.FormDataContentType())
+	return request
+}
+
+func TestBindRequest_MultipartForm(t *testing.T) {
+	ctx := &Context{rw: httptest.NewRecorder(), request: newMultipartRequest(t)}
+	if err := ctx.parseMultipartForm(); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	req := &uploadTestRequest{}
+	if err := newRequestBinder(reflect.TypeOf(req)).bind(ctx, req); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if req.Name != "test" {
+		t.Errorf("Expected name = test, got %s", req.Name)
+	}
+	if req.Avatar == nil || req.Avatar.Filename != "avatar.txt" {
+		t.Errorf("Expected avatar.txt, got %v", req.Avatar)
+	}
+	if len(req.Files) != 2 {
+		t.Errorf("Expected 2 files, got %d", len(req.Files))
+	}
+
+	file, header, err := ctx.FormFile("avatar")
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	defer file.Close()
+	if header.Size != int64(len("content of avatar")) {
+		t.Errorf("Expected size %d, got %d", len("content of avatar"), header.Size)
+	}
+}
+
+func TestParseMultipartForm_TooLarge(t *testing.T) {
+	ctx := &Context{rw: httptest.NewRecorder(), request: newMultipartRequest(t)}
+	UploadLimit(10)(ctx)
+
+	if err := ctx.parseMultipartForm(); err != HTTP_ERROR_REQUEST_ENTITY_TOO_LARGE {
+		t.Errorf("Expected HTTP_ERROR_REQUEST_ENTITY_TOO_LARGE, got %v", err)
+	}
+}
+
+func TestParseMultipartForm_MaxBodyBytes(t *testing.T) {
+	oldMaxBodyBytes := Config.Server.MaxBodyBytes
+	Config.Server.MaxBodyBytes = 100
+	t.Cleanup(func() { Config.Server.MaxBodyBytes = oldMaxBodyBytes })
+
+	ctx := &Context{rw: httptest.NewRecorder(), request: newMultipartRequest(t)}
+	if err := ctx.parseMultipartForm(); err != HTTP_ERROR_REQUEST_ENTITY_TOO_LARGE {
+		t.Errorf("Expected limit of config, got %v", err)
+	}
+
+	ctx = &Context{rw: httptest.NewRecorder(), request: newMultipartRequest(t)}
+	MaxBodyBytes(1 << 20)(ctx)
+	if err := ctx.parseMultipartForm(); err != nil {
+		t.Errorf("Expected limit of api overrides config, got %v", err)
+	}
+
+	ctx = &Context{rw: httptest.NewRecorder(), request: newMultipartRequest(t)}
+	UploadLimit(1 << 20)(ctx)
+	if err := ctx.parseMultipartForm(); err != nil {
+		t.Errorf("Expected upload limit is used before max body bytes, got %v", err)
+	}
+}
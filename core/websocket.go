@@ -0,0 +1,372 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	DEFAULT_WEBSOCKET_PONG_TIMEOUT  = 60 // Seconds
+	DEFAULT_WEBSOCKET_WRITE_TIMEOUT = 10 // Seconds
+)
+
+var errWebSocketClosed = errors.New("websocket connection is closed")
+
+/*
+* WebSocketHandler: handler of websocket api
+* Connection is closed when handler returns
+ */
+type WebSocketHandler func(ctx *Context, conn *WebSocketConn)
+
+/*
+* WebSocketConn: websocket connection of an api
+* Read functions must be called by one goroutine, write functions are safe for concurrent use
+ */
+type WebSocketConn struct {
+	// Copy of context of upgrade request, context of handler is returned to pool when handler returns
+	ctx        *Context
+	conn       *websocket.Conn
+	writeMutex sync.Mutex
+	isClosed   bool
+	closeHooks []func()
+	done       chan struct{}
+	wait       sync.WaitGroup
+}
+
+/*
+* RegisterWebSocket: register websocket api with method GET
+* Common middlewares and middlewares of api are run before connection is upgraded
+* Context of handler is not limited by context timeout, it is canceled when connection is closed
+* @params: url string
+* @params: handler WebSocketHandler
+* @params: middlewares ...Middleware
+* @return: void
+ */
+func RegisterWebSocket(url string, handler WebSocketHandler, middlewares ...Middleware) {
+	LoggerInstance.Info("Register websocket: %s", url)
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  Config.WebSocket.ReadBufferSize,
+		WriteBufferSize: Config.WebSocket.WriteBufferSize,
+	}
+
+	h := func(writer http.ResponseWriter, request *http.Request, params pathParams) {
+		ctx := getContext()
+		defer putContext(ctx)
+		defer ctx.recoverPanic()
+		buildContext(ctx, writer, request, params)
+		ctx.instrumentRequest(url)
+
+		middlewareList := []Middleware{}
+		middlewareList = append(middlewareList, commonMiddlewares...)
+		middlewareList = append(middlewareList, middlewares...)
+		if ctx.runMiddlewares(middlewareList) {
+			return
+		}
+
+		// Upgrader writes error response itself when upgrade fails
+		ctx.isResponseEnd = true
+		wsConn, err := upgrader.Upgrade(ctx.rw, request, nil)
+		if err != nil {
+			ctx.LogInfo("Upgrade websocket fail: %s", err.Error())
+			return
+		}
+
+		ctx.useStreamContext(request)
+
+		conn := newWebSocketConn(ctx, wsConn)
+		stop := context.AfterFunc(shutdownContext, conn.Close)
+		defer stop()
+		defer conn.Close()
+
+		ctx.LogInfo("Websocket is connected: %s", request.RemoteAddr)
+		handler(ctx, conn)
+	}
+
+	registerRoute(url, http.MethodGet, h, nil)
+}
+
+/*
+* newWebSocketConn: wrap connection, set read limit, pong handler and start ping loop
+ */
+func newWebSocketConn(ctx *Context, wsConn *websocket.Conn) *WebSocketConn {
+	conn := &WebSocketConn{
+		ctx:  &Context{Context: ctx.Context, cancelFunc: ctx.cancelFunc, requestID: ctx.requestID, URL: ctx.URL, Method: ctx.Method},
+		conn: wsConn,
+		done: make(chan struct{}),
+	}
+
+	pongTimeout := Config.WebSocket.GetPongTimeout()
+	if Config.WebSocket.MaxMessageSize > 0 {
+		wsConn.SetReadLimit(Config.WebSocket.MaxMessageSize)
+	}
+	wsConn.SetReadDeadline(time.Now().Add(pongTimeout))
+	wsConn.SetPongHandler(func(string) error {
+		return wsConn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+
+	conn.wait.Add(1)
+	go conn.ping(Config.WebSocket.GetPingInterval())
+	return conn
+}
+
+/*
+* Context: context of connection, logs of it have request id of upgrade request
+* It is canceled when connection is closed, it can be used after handler returns (by hub)
+* @return: *Context
+ */
+func (conn *WebSocketConn) Context() *Context {
+	return conn.ctx
+}
+
+/*
+* ReadMessage: read next message of connection
+* @return: int (websocket.TextMessage or websocket.BinaryMessage), []byte, error
+ */
+func (conn *WebSocketConn) ReadMessage() (int, []byte, error) {
+	messageType, data, err := conn.conn.ReadMessage()
+	if err != nil {
+		conn.readError(err)
+	}
+	return messageType, data, err
+}
+
+/*
+* ReadJSON: read next message of connection and decode it to v
+* @params: v any (pointer)
+* @return: error
+ */
+func (conn *WebSocketConn) ReadJSON(v any) error {
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+/*
+* WriteMessage: write a message to connection
+* @params: messageType int (websocket.TextMessage or websocket.BinaryMessage)
+* @params: data []byte
+* @return: error
+ */
+func (conn *WebSocketConn) WriteMessage(messageType int, data []byte) error {
+	conn.writeMutex.Lock()
+	defer conn.writeMutex.Unlock()
+
+	if conn.isClosed {
+		return errWebSocketClosed
+	}
+	conn.conn.SetWriteDeadline(time.Now().Add(Config.WebSocket.GetWriteTimeout()))
+	return conn.conn.WriteMessage(messageType, data)
+}
+
+/*
+* WriteJSON: encode v to json and write it as text message
+* @params: v any
+* @return: error
+ */
+func (conn *WebSocketConn) WriteJSON(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
+
+/*
+* Close: send close message, close connection and leave all groups of hubs
+* It is called automatically when handler returns
+* @return: void
+ */
+func (conn *WebSocketConn) Close() {
+	conn.writeMutex.Lock()
+	if conn.isClosed {
+		conn.writeMutex.Unlock()
+		return
+	}
+	conn.isClosed = true
+	closeHooks := conn.closeHooks
+	conn.closeHooks = nil
+
+	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, BLANK)
+	conn.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(Config.WebSocket.GetWriteTimeout()))
+	conn.conn.Close()
+	conn.writeMutex.Unlock()
+
+	close(conn.done)
+	conn.wait.Wait()
+	for _, hook := range closeHooks {
+		hook()
+	}
+	conn.ctx.cancelFunc()
+	conn.ctx.LogInfo("Websocket is closed")
+}
+
+/*
+* onClose: add function that is called when connection is closed
+ */
+func (conn *WebSocketConn) onClose(hook func()) bool {
+	conn.writeMutex.Lock()
+	defer conn.writeMutex.Unlock()
+
+	if conn.isClosed {
+		return false
+	}
+	conn.closeHooks = append(conn.closeHooks, hook)
+	return true
+}
+
+/*
+* ping: send ping periodically, connection is closed by read deadline if pong is not received
+ */
+func (conn *WebSocketConn) ping(interval time.Duration) {
+	defer conn.wait.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-conn.done:
+			return
+		case <-ticker.C:
+			deadline := time.Now().Add(Config.WebSocket.GetWriteTimeout())
+			if err := conn.conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				conn.ctx.LogInfo("Send ping fail: %s", err.Error())
+				return
+			}
+		}
+	}
+}
+
+/*
+* readError: log error of read, normal close of client is not logged as error
+ */
+func (conn *WebSocketConn) readError(err error) {
+	if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+		return
+	}
+
+	conn.writeMutex.Lock()
+	isClosed := conn.isClosed
+	conn.writeMutex.Unlock()
+	if !isClosed {
+		conn.ctx.LogInfo("Read websocket message fail: %s", err.Error())
+	}
+}
+
+/*
+* Hub: groups of websocket connections, message is broadcast to all connections of a group
+ */
+type Hub struct {
+	mutex  sync.RWMutex
+	groups map[string]map[*WebSocketConn]struct{}
+}
+
+/*
+* NewHub: create a hub
+* @return: *Hub
+ */
+func NewHub() *Hub {
+	return &Hub{
+		groups: make(map[string]map[*WebSocketConn]struct{}),
+	}
+}
+
+/*
+* Join: add connection to group, connection leaves group when it is closed
+* @params: group string
+* @params: conn *WebSocketConn
+* @return: void
+ */
+func (hub *Hub) Join(group string, conn *WebSocketConn) {
+	hub.mutex.Lock()
+	conns, ok := hub.groups[group]
+	if !ok {
+		conns = make(map[*WebSocketConn]struct{})
+		hub.groups[group] = conns
+	}
+	conns[conn] = struct{}{}
+	hub.mutex.Unlock()
+
+	if !conn.onClose(func() { hub.Leave(group, conn) }) {
+		hub.Leave(group, conn)
+	}
+}
+
+/*
+* Leave: remove connection from group
+* @params: group string
+* @params: conn *WebSocketConn
+* @return: void
+ */
+func (hub *Hub) Leave(group string, conn *WebSocketConn) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	conns, ok := hub.groups[group]
+	if !ok {
+		return
+	}
+	delete(conns, conn)
+	if len(conns) == 0 {
+		delete(hub.groups, group)
+	}
+}
+
+/*
+* Count: number of connections of group
+* @params: group string
+* @return: int
+ */
+func (hub *Hub) Count(group string) int {
+	hub.mutex.RLock()
+	defer hub.mutex.RUnlock()
+	return len(hub.groups[group])
+}
+
+/*
+* Broadcast: encode v to json once and write it to all connections of group
+* Connection that fails to write is closed
+* @params: group string
+* @params: v any
+* @return: error (encode fail)
+ */
+func (hub *Hub) Broadcast(group string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	hub.BroadcastMessage(group, websocket.TextMessage, data)
+	return nil
+}
+
+/*
+* BroadcastMessage: write a message to all connections of group
+* @params: group string
+* @params: messageType int
+* @params: data []byte
+* @return: void
+ */
+func (hub *Hub) BroadcastMessage(group string, messageType int, data []byte) {
+	hub.mutex.RLock()
+	conns := make([]*WebSocketConn, 0, len(hub.groups[group]))
+	for conn := range hub.groups[group] {
+		conns = append(conns, conn)
+	}
+	hub.mutex.RUnlock()
+
+	for _, conn := range conns {
+		if err := conn.WriteMessage(messageType, data); err != nil {
+			if err != errWebSocketClosed {
+				conn.ctx.LogInfo("Broadcast to group %s fail: %s", group, err.Error())
+				go conn.Close()
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func newTestWebSocketServer(t *testing.T, hub *Hub, joined chan *WebSocketConn) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		wsConn, err := upgrader.Upgrade(writer, request, nil)
+		if err != nil {
+			t.Errorf("Upgrade fail: %s", err.Error())
+			return
+		}
+
+		ctx := &Context{requestID: "1"}
+		ctx.Context, ctx.cancelFunc = context.WithCancel(context.Background())
+		conn := newWebSocketConn(ctx, wsConn)
+		defer conn.Close()
+
+		hub.Join("room", conn)
+		joined <- conn
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func TestHub_Broadcast(t *testing.T) {
+	hub := NewHub()
+	joined := make(chan *WebSocketConn, 2)
+	server := newTestWebSocketServer(t, hub, joined)
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	clients := []*websocket.Conn{}
+	for i := 0; i < 2; i++ {
+		client, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("Dial fail: %s", err.Error())
+		}
+		defer client.Close()
+		clients = append(clients, client)
+		<-joined
+	}
+
+	if count := hub.Count("room"); count != 2 {
+		t.Fatalf("Expected 2 connections, got %d", count)
+	}
+	if err := hub.Broadcast("room", map[string]string{"name": "test"}); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	for _, client := range clients {
+		message := map[string]string{}
+		if err := client.ReadJSON(&message); err != nil {
+			t.Fatalf("Read message fail: %s", err.Error())
+		}
+		if message["name"] != "test" {
+			t.Errorf("Expected name = test, got %v", message)
+		}
+	}
+}
+
+func TestHub_LeaveOnClose(t *testing.T) {
+	hub := NewHub()
+	joined := make(chan *WebSocketConn, 1)
+	server := newTestWebSocketServer(t, hub, joined)
+	defer server.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial fail: %s", err.Error())
+	}
+	defer client.Close()
+
+	conn := <-joined
+	conn.Close()
+
+	if count := hub.Count("room"); count != 0 {
+		t.Errorf("Expected 0 connections, got %d", count)
+	}
+	if err := conn.WriteJSON("test"); err != errWebSocketClosed {
+		t.Errorf("Expected errWebSocketClosed, got %v", err)
+	}
+}
+
+func TestWebSocketConn_ContextIsNotPooled(t *testing.T) {
+	joined := make(chan *WebSocketConn, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		wsConn, err := upgrader.Upgrade(writer, request, nil)
+		if err != nil {
+			t.Errorf("Upgrade fail: %s", err.Error())
+			return
+		}
+
+		ctx := &Context{requestID: "1"}
+		ctx.Context, ctx.cancelFunc = context.WithCancel(context.Background())
+		conn := newWebSocketConn(ctx, wsConn)
+		// Context of handler is reused by other request after it is returned to pool
+		*ctx = Context{}
+		joined <- conn
+	}))
+	defer server.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial fail: %s", err.Error())
+	}
+	defer client.Close()
+
+	conn := <-joined
+	conn.Close()
+	if conn.Context().GetRequestID() != "1" || conn.Context().Err() == nil {
+		t.Errorf("Expected context of connection keeps request id and is canceled, got %s %v", conn.Context().GetRequestID(), conn.Context().Err())
+	}
+}
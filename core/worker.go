@@ -81,24 +81,37 @@ func (w *worker) execute() {
 		})
 	}
 
+	schedulerTodosDue.Set(float64(len(todos)))
 	if len(todos) == 0 {
+		schedulerLag.Set(0)
 		return
 	}
 
+	oldestBucket := todos[0].bucket
+	for _, todo := range todos {
+		if todo.bucket < oldestBucket {
+			oldestBucket = todo.bucket
+		}
+	}
+	schedulerLag.Set(float64((bucket - oldestBucket) * int64(Config.Scheduler.BucketSize)))
+
 	for _, todo := range todos {
 		// Block this task by redis or lwt in database: use distributed log
 		taskKey := fmt.Sprintf(TASK_TEMPLATE_KEY, todo.taskId)
 		result, err := CacheClient().SetNX(coreContext, taskKey, string(TaskStatus_Doing), time.Duration(Config.Scheduler.TaskDoingExpiration)*time.Second).Result()
 		if err != nil {
 			LoggerInstance.Info("Set %s fail: %v", taskKey, err)
+			schedulerLockContention.WithLabelValues("error").Inc()
 			continue
 		} else if !result {
 			LoggerInstance.Info("Key %s existed", taskKey)
+			schedulerLockContention.WithLabelValues("held").Inc()
 			continue
 		}
 		// Process data
 		LoggerInstance.Debug("Execute task: %s", todo.taskId)
 		w.process(taskKey, todo.bucket, todo.taskId)
+		schedulerTasksProcessed.Inc()
 	}
 }
 
@@ -148,7 +161,8 @@ func (w *worker) process(taskKey string, bucket int64, id string) {
 	} else {
 		// Do task
 		defer session.CloseSession()
-		err = session.Publish(t.Data)
+		// Task is not started by a request, message has no request id
+		err = session.PublishWithContext(coreContext, t.Data)
 		if err != nil {
 			LoggerInstance.Error("Cannot run task: %v", t)
 		}
@@ -160,9 +174,10 @@ func (w *worker) process(taskKey string, bucket int64, id string) {
 		next := time.Unix(t.Next, 0)
 		newBucket := GetBucket(next)
 		// Update new task in table: todo, task (time of next task)
-		tx, err := DBSession().BeginTx(coreContext, &sql.TxOptions{})
+		tx, err := DBSession().BeginTxTraced(coreContext, &sql.TxOptions{})
 		if err != nil {
-			LoggerInstance.Error("Start transaction fail: %v")
+			LoggerInstance.Error("Start transaction fail: %v", err)
+			return
 		}
 		defer tx.Rollback()
 		// Delete old todo
@@ -187,9 +202,10 @@ func (w *worker) process(taskKey string, bucket int64, id string) {
 		}
 
 	} else {
-		tx, err := DBSession().BeginTx(coreContext, &sql.TxOptions{})
+		tx, err := DBSession().BeginTxTraced(coreContext, &sql.TxOptions{})
 		if err != nil {
-			LoggerInstance.Error("Start transaction fail: %v")
+			LoggerInstance.Error("Start transaction fail: %v", err)
+			return
 		}
 		defer tx.Rollback()
 